@@ -0,0 +1,383 @@
+package genx
+
+import (
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing/fstest"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type ChangeOp string
+
+const (
+	ChangeOpWrite  ChangeOp = "write"
+	ChangeOpDelete ChangeOp = "delete"
+)
+
+type Change struct {
+	Op      ChangeOp
+	RelPath string
+	Content string
+}
+
+// Changeset collects the planned changes of a dry run, paths are FS names relative to the output dir.
+type Changeset struct {
+	mu      sync.Mutex
+	changes map[string]*Change
+}
+
+func NewChangeset() *Changeset {
+	return &Changeset{changes: make(map[string]*Change)}
+}
+
+func (c *Changeset) Write(relPath, content string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	relPath = FSName(relPath)
+	c.changes[relPath] = &Change{Op: ChangeOpWrite, RelPath: relPath, Content: content}
+}
+
+func (c *Changeset) Delete(relPath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	relPath = FSName(relPath)
+	c.changes[relPath] = &Change{Op: ChangeOpDelete, RelPath: relPath}
+}
+
+// Changes returns the planned changes sorted by path.
+func (c *Changeset) Changes() []*Change {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changes := make([]*Change, 0, len(c.changes))
+	for _, change := range c.changes {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].RelPath < changes[j].RelPath
+	})
+	return changes
+}
+
+func (c *Changeset) get(name string) (*Change, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	change, ok := c.changes[name]
+	return change, ok
+}
+
+// Apply performs the planned changes in fsys.
+func (c *Changeset) Apply(fsys FS) error {
+	for _, change := range c.Changes() {
+		switch change.Op {
+		case ChangeOpWrite:
+			dir := path.Dir(change.RelPath)
+			if err := fsys.MkdirAll(dir, os.ModePerm); err != nil {
+				return errors.Wrapf(err, "failed to create directory %s", dir)
+			}
+			if err := fsys.WriteFile(change.RelPath, []byte(change.Content), 0o644); err != nil {
+				return errors.Wrapf(err, "failed to write file %s", change.RelPath)
+			}
+		case ChangeOpDelete:
+			if err := fsys.Remove(change.RelPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return errors.Wrapf(err, "failed to remove %s", change.RelPath)
+			}
+		}
+	}
+	return nil
+}
+
+// Commit performs the planned changes in fsys like Apply but restores the previous state of fsys if one of them fails,
+// writes that would not modify a file are skipped.
+func (c *Changeset) Commit(fsys FS) error {
+	return c.commit(fsys, nil)
+}
+
+// commit is Commit calling onChange for every change once all of them succeeded,
+// modified is false for the writes that were skipped.
+func (c *Changeset) commit(fsys FS, onChange func(change *Change, modified bool)) (err error) {
+	type committed struct {
+		change   *Change
+		modified bool
+	}
+	var changes []committed
+	var undo []func() error
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](); undoErr != nil {
+				err = stderrors.Join(err, errors.Wrap(undoErr, "failed to roll back"))
+			}
+		}
+	}()
+
+	for _, change := range c.Changes() {
+		name := change.RelPath
+		prev, readErr := fs.ReadFile(fsys, name)
+		existed := readErr == nil
+		if readErr != nil && !errors.Is(readErr, fs.ErrNotExist) {
+			return errors.Wrapf(readErr, "failed to read file %s", name)
+		}
+		perm := fs.FileMode(0o644)
+		if existed {
+			if info, err := fs.Stat(fsys, name); err == nil {
+				perm = info.Mode().Perm()
+			}
+		}
+		restore := func() error {
+			return fsys.WriteFile(name, prev, perm)
+		}
+
+		switch change.Op {
+		case ChangeOpWrite:
+			if existed && string(prev) == change.Content {
+				changes = append(changes, committed{change, false})
+				continue
+			}
+			var missingDirs []string
+			for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+				if _, err := fs.Stat(fsys, dir); !errors.Is(err, fs.ErrNotExist) {
+					break
+				}
+				missingDirs = append(missingDirs, dir)
+			}
+			if len(missingDirs) > 0 {
+				if err := fsys.MkdirAll(path.Dir(name), os.ModePerm); err != nil {
+					return errors.Wrapf(err, "failed to create directory %s", path.Dir(name))
+				}
+				undo = append(undo, func() error {
+					for _, dir := range missingDirs {
+						if err := fsys.Remove(dir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+							return err
+						}
+					}
+					return nil
+				})
+			}
+			if err := fsys.WriteFile(name, []byte(change.Content), 0o644); err != nil {
+				return errors.Wrapf(err, "failed to write file %s", name)
+			}
+			if existed {
+				undo = append(undo, restore)
+			} else {
+				undo = append(undo, func() error { return fsys.Remove(name) })
+			}
+			changes = append(changes, committed{change, true})
+		case ChangeOpDelete:
+			if !existed {
+				continue
+			}
+			if err := fsys.Remove(name); err != nil {
+				return errors.Wrapf(err, "failed to remove %s", name)
+			}
+			undo = append(undo, restore)
+			changes = append(changes, committed{change, true})
+		}
+	}
+	if onChange != nil {
+		for _, cc := range changes {
+			onChange(cc.change, cc.modified)
+		}
+	}
+	return nil
+}
+
+// Overlay returns a FS which reads through the planned changes onto base and records every write into the changeset.
+func (c *Changeset) Overlay(base fs.FS) FS {
+	return &changesetFS{base: base, changeset: c}
+}
+
+type FileStatus string
+
+const (
+	FileStatusAdded    FileStatus = "A"
+	FileStatusModified FileStatus = "M"
+	FileStatusDeleted  FileStatus = "D"
+)
+
+type FileChange struct {
+	Status  FileStatus
+	RelPath string
+	Before  string
+	After   string
+}
+
+func (fc *FileChange) UnifiedDiff() (string, error) {
+	ud := difflib.UnifiedDiff{
+		A:        splitLines(fc.Before),
+		B:        splitLines(fc.After),
+		FromFile: path.Join("a", fc.RelPath),
+		ToFile:   path.Join("b", fc.RelPath),
+		Context:  3,
+	}
+	if fc.Status == FileStatusAdded {
+		ud.A = nil
+		ud.FromFile = "/dev/null"
+	}
+	if fc.Status == FileStatusDeleted {
+		ud.B = nil
+		ud.ToFile = "/dev/null"
+	}
+	diff, err := difflib.GetUnifiedDiffString(ud)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to diff %s", fc.RelPath)
+	}
+	return diff, nil
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
+
+// Compare compares the planned changes with the files in fsys, changes that would not modify anything are omitted.
+func (c *Changeset) Compare(fsys fs.FS) ([]*FileChange, error) {
+	var fileChanges []*FileChange
+	for _, change := range c.Changes() {
+		content, err := fs.ReadFile(fsys, change.RelPath)
+		exists := err == nil
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.Wrapf(err, "failed to read file %s", change.RelPath)
+		}
+
+		switch change.Op {
+		case ChangeOpWrite:
+			if !exists {
+				fileChanges = append(fileChanges, &FileChange{Status: FileStatusAdded, RelPath: change.RelPath, After: change.Content})
+				continue
+			}
+			if string(content) == change.Content {
+				continue
+			}
+			fileChanges = append(fileChanges, &FileChange{Status: FileStatusModified, RelPath: change.RelPath, Before: string(content), After: change.Content})
+		case ChangeOpDelete:
+			if !exists {
+				continue
+			}
+			fileChanges = append(fileChanges, &FileChange{Status: FileStatusDeleted, RelPath: change.RelPath, Before: string(content)})
+		}
+	}
+	return fileChanges, nil
+}
+
+// Diff returns the unified diff of the planned changes against the files in fsys.
+func (c *Changeset) Diff(fsys fs.FS) (string, error) {
+	fileChanges, err := c.Compare(fsys)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, fc := range fileChanges {
+		diff, err := fc.UnifiedDiff()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(diff)
+	}
+	return sb.String(), nil
+}
+
+var _ FS = (*changesetFS)(nil)
+
+type changesetFS struct {
+	base      fs.FS
+	changeset *Changeset
+}
+
+func (f *changesetFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if change, ok := f.changeset.get(name); ok {
+		if change.Op == ChangeOpDelete {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return fstest.MapFS{name: &fstest.MapFile{Data: []byte(change.Content), Mode: 0o644}}.Open(name)
+	}
+
+	baseFile, baseErr := f.base.Open(name)
+	if baseErr != nil && !errors.Is(baseErr, fs.ErrNotExist) {
+		return nil, baseErr
+	}
+	if baseErr == nil {
+		info, err := baseFile.Stat()
+		if err != nil {
+			baseFile.Close()
+			return nil, err
+		}
+		if !info.IsDir() {
+			return baseFile, nil
+		}
+		baseFile.Close()
+	}
+
+	// directory, merge the entries of base with the planned changes
+	entries := fstest.MapFS{}
+	join := func(elem string) string {
+		if name == "." {
+			return elem
+		}
+		return name + "/" + elem
+	}
+	if baseErr == nil {
+		baseEntries, err := fs.ReadDir(f.base, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range baseEntries {
+			entries[join(e.Name())] = &fstest.MapFile{Mode: e.Type()}
+		}
+	}
+	prefix := join("")
+	for _, change := range f.changeset.Changes() {
+		if !strings.HasPrefix(change.RelPath, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(change.RelPath, prefix)
+		if elem, _, isDir := strings.Cut(rest, "/"); isDir {
+			if change.Op == ChangeOpWrite {
+				entries[join(elem)] = &fstest.MapFile{Mode: fs.ModeDir}
+			}
+			continue
+		}
+		if change.Op == ChangeOpDelete {
+			delete(entries, change.RelPath)
+			continue
+		}
+		entries[change.RelPath] = &fstest.MapFile{}
+	}
+	if baseErr != nil && len(entries) == 0 {
+		return nil, baseErr
+	}
+	return entries.Open(name)
+}
+
+func (f *changesetFS) WriteFile(name string, data []byte, _ fs.FileMode) error {
+	f.changeset.Write(name, string(data))
+	return nil
+}
+
+func (f *changesetFS) Remove(name string) error {
+	f.changeset.Delete(name)
+	return nil
+}
+
+func (f *changesetFS) MkdirAll(string, fs.FileMode) error {
+	return nil
+}
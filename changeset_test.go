@@ -0,0 +1,146 @@
+package genx
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeset(t *testing.T) {
+	fsys := NewMemFS()
+	require.NoError(t, fsys.WriteFile("same.genx.go", []byte("package a\n"), 0o644))
+	require.NoError(t, fsys.WriteFile("modified.genx.go", []byte("package a\n\nvar A = 1\n"), 0o644))
+	require.NoError(t, fsys.WriteFile("stale.genx.go", []byte("package a\n"), 0o644))
+
+	cs := NewChangeset()
+	cs.Write("same.genx.go", "package a\n")
+	cs.Write("modified.genx.go", "package a\n\nvar A = 2\n")
+	cs.Write("sub/added.genx.go", "package sub\n")
+	cs.Delete("stale.genx.go")
+	cs.Delete("missing.genx.go")
+
+	fileChanges, err := cs.Compare(fsys)
+	require.NoError(t, err)
+	var got []string
+	for _, fc := range fileChanges {
+		got = append(got, string(fc.Status)+" "+fc.RelPath)
+	}
+	assert.Equal(t, []string{
+		"M modified.genx.go",
+		"D stale.genx.go",
+		"A sub/added.genx.go",
+	}, got)
+
+	diff, err := cs.Diff(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, `--- a/modified.genx.go
++++ b/modified.genx.go
+@@ -1,3 +1,3 @@
+ package a
+ 
+-var A = 1
++var A = 2
+--- a/stale.genx.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package a
+--- /dev/null
++++ b/sub/added.genx.go
+@@ -0,0 +1 @@
++package sub
+`, diff)
+
+	// the overlay reads through the planned changes
+	overlay := cs.Overlay(fsys)
+	var names []string
+	require.NoError(t, fs.WalkDir(overlay, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			names = append(names, name)
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"modified.genx.go", "same.genx.go", "sub/added.genx.go"}, names)
+	content, err := fs.ReadFile(overlay, "modified.genx.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n\nvar A = 2\n", string(content))
+
+	// nothing touched in the base
+	assert.Equal(t, []string{"modified.genx.go", "same.genx.go", "stale.genx.go"}, fsys.Names())
+
+	require.NoError(t, cs.Apply(fsys))
+	assert.Equal(t, []string{"modified.genx.go", "same.genx.go", "sub/added.genx.go"}, fsys.Names())
+	fileChanges, err = cs.Compare(fsys)
+	require.NoError(t, err)
+	assert.Empty(t, fileChanges)
+}
+
+// failingFS fails to write the named file.
+type failingFS struct {
+	*MemFS
+	name string
+}
+
+func (f *failingFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if name == f.name {
+		return errors.New("disk full")
+	}
+	return f.MemFS.WriteFile(name, data, perm)
+}
+
+func TestChangesetCommit(t *testing.T) {
+	fsys := NewMemFS()
+	require.NoError(t, fsys.WriteFile("a.genx.go", []byte("package a\n"), 0o600))
+	require.NoError(t, fsys.WriteFile("stale.genx.go", []byte("package stale\n"), 0o640))
+
+	cs := NewChangeset()
+	cs.Write("a.genx.go", "package a\n\nvar A = 1\n")
+	cs.Delete("stale.genx.go")
+	cs.Write("sub/dir/b.genx.go", "package dir\n")
+	cs.Write("z.genx.go", "package z\n")
+
+	err := cs.Commit(&failingFS{MemFS: fsys, name: "z.genx.go"})
+	require.ErrorContains(t, err, "failed to write file z.genx.go: disk full")
+	assert.Equal(t, []string{"a.genx.go", "stale.genx.go"}, fsys.Names())
+	content, err := fs.ReadFile(fsys, "a.genx.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n", string(content))
+	_, err = fs.Stat(fsys, "sub")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	info, err := fs.Stat(fsys, "stale.genx.go")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o640), info.Mode().Perm())
+
+	require.NoError(t, cs.Commit(fsys))
+	assert.Equal(t, []string{"a.genx.go", "sub/dir/b.genx.go", "z.genx.go"}, fsys.Names())
+	info, err = fs.Stat(fsys, "a.genx.go")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestOSFSWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	fsys := OSFS(dir)
+	require.NoError(t, fsys.WriteFile("a.genx.go", []byte("package a\n"), 0o644))
+	require.NoError(t, os.Chmod(filepath.Join(dir, "a.genx.go"), 0o600))
+
+	require.NoError(t, fsys.WriteFile("a.genx.go", []byte("package a\n\nvar A = 1\n"), 0o644))
+	info, err := os.Stat(filepath.Join(dir, "a.genx.go"))
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+	content, err := os.ReadFile(filepath.Join(dir, "a.genx.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n\nvar A = 1\n", string(content))
+
+	// no temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
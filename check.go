@@ -0,0 +1,81 @@
+package genx
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var reGeneratedFileSuffix = regexp.MustCompile(`\.genx\.\w+$`)
+
+// IsGeneratedFile reports whether the file is owned by genx according to its name.
+func IsGeneratedFile(path string) bool {
+	return reGeneratedFileSuffix.MatchString(filepath.Base(path))
+}
+
+type CheckReport struct {
+	// Target is the Config.Target of the run
+	Target string
+	// Stale files exist on disk but differ from the generated output
+	Stale []string
+	// Missing files would be generated but do not exist on disk
+	Missing []string
+	// Extra files exist on disk but would be removed
+	Extra []string
+}
+
+func (r *CheckReport) OK() bool {
+	return len(r.Stale) == 0 && len(r.Missing) == 0 && len(r.Extra) == 0
+}
+
+func (r *CheckReport) String() string {
+	var sb strings.Builder
+	prefix := ""
+	if r.Target != "" {
+		prefix = "[" + r.Target + "] "
+	}
+	for _, v := range []struct {
+		title string
+		files []string
+	}{
+		{"stale", r.Stale},
+		{"missing", r.Missing},
+		{"extra", r.Extra},
+	} {
+		for _, file := range v.files {
+			fmt.Fprintf(&sb, "%s%s: %s\n", prefix, v.title, file)
+		}
+	}
+	return sb.String()
+}
+
+// Check runs the whole pipeline in dry run mode and reports the generated files on disk that are out of date.
+func Check(ctx context.Context, config *Config, options ...Option) (*CheckReport, error) {
+	changeset := NewChangeset()
+	options = append(options, DryRun(changeset))
+	if err := Generate(ctx, config, options...); err != nil {
+		return nil, err
+	}
+	return newCheckReport(changeset, config.outputFS())
+}
+
+func newCheckReport(changeset *Changeset, fsys FS) (*CheckReport, error) {
+	fileChanges, err := changeset.Compare(fsys)
+	if err != nil {
+		return nil, err
+	}
+	report := &CheckReport{}
+	for _, fc := range fileChanges {
+		switch fc.Status {
+		case FileStatusModified:
+			report.Stale = append(report.Stale, fc.RelPath)
+		case FileStatusAdded:
+			report.Missing = append(report.Missing, fc.RelPath)
+		case FileStatusDeleted:
+			report.Extra = append(report.Extra, fc.RelPath)
+		}
+	}
+	return report, nil
+}
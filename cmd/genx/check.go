@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/molon/genx/generator"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check that the generated files are up to date, exits non-zero otherwise",
+	Run: func(cmd *cobra.Command, args []string) {
+		confPath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			log.Fatalf("Failed to get config path: %v", err)
+		}
+
+		conf, err := generator.LoadConfig(confPath)
+		if err != nil {
+			log.Fatalf("Failed to load config: %+v", err)
+		}
+
+		reports, err := generator.Check(context.Background(), conf, logOptions(cmd, outputDirs(conf))...)
+		if err != nil {
+			fatalf("Failed to check: %+v", err)
+		}
+		ok := true
+		for _, report := range reports {
+			if !report.OK() {
+				fmt.Fprint(cmd.ErrOrStderr(), report.String())
+				ok = false
+			}
+		}
+		if !ok {
+			fmt.Fprintln(cmd.ErrOrStderr(), "generated files are out of date, please run genx generate")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().String("config", generator.DefaultConfigFile, "path to the genx configuration yaml file")
+	addLogFlags(checkCmd)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/generator"
+	"github.com/pkg/errors"
+)
+
+// outputDirs returns the output dir of every target of conf by name, the name of a config without targets is empty.
+func outputDirs(conf *generator.Config) map[string]string {
+	dirs := make(map[string]string)
+	for _, c := range conf.TargetConfigs() {
+		dirs[c.Target] = c.OutputDir
+	}
+	return dirs
+}
+
+// printDiagnostics prints every diagnostic compiler-style as soon as it is reported,
+// the files are shown relative to the working directory so that editors can jump to them.
+// Info diagnostics are left out in quiet mode.
+func printDiagnostics(w io.Writer, outputDirs map[string]string, quiet bool) genx.Option {
+	return genx.OnDiagnostic(func(d *genx.Diagnostic) {
+		if quiet && d.Severity == genx.SeverityInfo {
+			return
+		}
+		fmt.Fprintln(w, relativeDiagnostic(d, outputDirs))
+	})
+}
+
+// relativeDiagnostic returns a copy of d with its file relative to the working directory.
+func relativeDiagnostic(d *genx.Diagnostic, outputDirs map[string]string) *genx.Diagnostic {
+	if d.File == "" || filepath.IsAbs(d.File) {
+		return d
+	}
+	file := filepath.Join(outputDirs[d.Target], filepath.FromSlash(d.File))
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, file); err == nil {
+			file = rel
+		}
+	}
+	dd := *d
+	dd.File = file
+	return &dd
+}
+
+// fatalf exits non-zero, the diagnostics of err are not repeated since they have been printed already.
+func fatalf(format string, err error) {
+	var ds genx.Diagnostics
+	if errors.As(err, &ds) {
+		os.Exit(1)
+	}
+	log.Fatalf(format, err)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/generator"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// printWatchRun prints the outcome of every run in watch mode, the diagnostics are printed as they are reported.
+func printWatchRun(w io.Writer) func(run *generator.WatchRun) {
+	return func(run *generator.WatchRun) {
+		if run.Target != "" {
+			fmt.Fprintf(w, "[%s] ", run.Target)
+		}
+		var ds genx.Diagnostics
+		switch {
+		case errors.As(run.Err, &ds):
+			fmt.Fprintf(w, "generation failed, watching for changes\n")
+		case run.Err != nil:
+			fmt.Fprintf(w, "generation failed: %+v\nwatching for changes\n", run.Err)
+		case len(run.Changed) == 0:
+			fmt.Fprintf(w, "generated in %s, watching for changes\n", run.Duration.Round(time.Millisecond))
+		default:
+			skipped := ""
+			if run.GoHooksOnly {
+				skipped = " (gqlgen skipped)"
+			}
+			fmt.Fprintf(w, "regenerated after changes to %s in %s%s\n", strings.Join(run.Changed, ", "), run.Duration.Round(time.Millisecond), skipped)
+		}
+	}
+}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate code according to the genx.yaml configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		confPath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			log.Fatalf("Failed to get config path: %v", err)
+		}
+
+		conf, err := generator.LoadConfig(confPath)
+		if err != nil {
+			log.Fatalf("Failed to load config: %+v", err)
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			log.Fatalf("Failed to get dry-run flag: %v", err)
+		}
+		diff, err := cmd.Flags().GetBool("diff")
+		if err != nil {
+			log.Fatalf("Failed to get diff flag: %v", err)
+		}
+
+		watch, err := cmd.Flags().GetBool("watch")
+		if err != nil {
+			log.Fatalf("Failed to get watch flag: %v", err)
+		}
+
+		logging := logOptions(cmd, outputDirs(conf))
+		if watch {
+			if dryRun || diff {
+				log.Fatalf("--watch cannot be combined with --dry-run or --diff")
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err := generator.Watch(ctx, conf, printWatchRun(cmd.ErrOrStderr()), logging...); err != nil {
+				log.Fatalf("Failed to watch: %+v", err)
+			}
+			return
+		}
+
+		if !dryRun && !diff {
+			if err := generator.Generate(context.Background(), conf, logging...); err != nil {
+				fatalf("Failed to generate: %+v", err)
+			}
+			return
+		}
+
+		// the targets are planned one after another so that their changes are printed apart
+		for _, c := range conf.TargetConfigs() {
+			prefix := ""
+			if c.Target != "" {
+				prefix = "[" + c.Target + "] "
+			}
+			changeset := genx.NewChangeset()
+			if err := generator.Generate(context.Background(), c, append(logging, genx.DryRun(changeset))...); err != nil {
+				fatalf("Failed to generate: %+v", err)
+			}
+			if diff {
+				text, err := changeset.Diff(genx.OSFS(c.OutputDir))
+				if err != nil {
+					log.Fatalf("Failed to diff: %+v", err)
+				}
+				if prefix != "" && text != "" {
+					fmt.Fprintf(cmd.OutOrStdout(), "# %s%s\n", prefix, c.OutputDir)
+				}
+				fmt.Fprint(cmd.OutOrStdout(), text)
+				continue
+			}
+			fileChanges, err := changeset.Compare(genx.OSFS(c.OutputDir))
+			if err != nil {
+				log.Fatalf("Failed to compare: %+v", err)
+			}
+			for _, fc := range fileChanges {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s%s %s\n", prefix, fc.Status, fc.RelPath)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().String("config", generator.DefaultConfigFile, "path to the genx configuration yaml file")
+	generateCmd.Flags().Bool("dry-run", false, "list the planned changes without touching the working tree")
+	generateCmd.Flags().Bool("diff", false, "print the unified diff of the planned changes without touching the working tree")
+	generateCmd.Flags().Bool("watch", false, "regenerate whenever the prototype or the go hook files change")
+	addLogFlags(generateCmd)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/molon/genx"
+	"github.com/spf13/cobra"
+)
+
+func addLogFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("verbose", "v", false, "log every phase, extension, formatter and file")
+	cmd.Flags().BoolP("quiet", "q", false, "only print warnings and errors")
+	cmd.Flags().Bool("json", false, "print the logs and diagnostics as JSON lines")
+}
+
+// logOptions renders the events of the run and its diagnostics to the stderr of cmd according to its log flags.
+func logOptions(cmd *cobra.Command, outputDirs map[string]string) []genx.Option {
+	verbose, err := cmd.Flags().GetBool("verbose")
+	if err != nil {
+		log.Fatalf("Failed to get verbose flag: %v", err)
+	}
+	quiet, err := cmd.Flags().GetBool("quiet")
+	if err != nil {
+		log.Fatalf("Failed to get quiet flag: %v", err)
+	}
+	jsonOutput, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		log.Fatalf("Failed to get json flag: %v", err)
+	}
+	if verbose && quiet {
+		log.Fatalf("--verbose cannot be combined with --quiet")
+	}
+
+	level := slog.LevelInfo
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelWarn
+	}
+
+	w := cmd.ErrOrStderr()
+	if !jsonOutput {
+		return []genx.Option{
+			genx.Logger(slog.New(&textHandler{w: w, mu: &sync.Mutex{}, level: level})),
+			printDiagnostics(w, outputDirs, quiet),
+		}
+	}
+
+	logger := slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+	return []genx.Option{
+		genx.Logger(logger),
+		genx.OnDiagnostic(func(d *genx.Diagnostic) {
+			d = relativeDiagnostic(d, outputDirs)
+			attrs := []slog.Attr{slog.String("severity", string(d.Severity))}
+			if d.File != "" {
+				attrs = append(attrs, slog.String("file", d.File), slog.Int("line", d.Line), slog.Int("column", d.Column))
+			}
+			if d.Extension != "" {
+				attrs = append(attrs, slog.String("extension", d.Extension))
+			}
+			if d.Target != "" {
+				attrs = append(attrs, slog.String("target", d.Target))
+			}
+			logger.LogAttrs(context.Background(), severityLevels[d.Severity], d.Message, attrs...)
+		}),
+	}
+}
+
+var severityLevels = map[genx.Severity]slog.Level{
+	genx.SeverityError:   slog.LevelError,
+	genx.SeverityWarning: slog.LevelWarn,
+	genx.SeverityInfo:    slog.LevelInfo,
+}
+
+// textHandler prints the records as the message followed by the attributes, without time and level unless it is not info.
+type textHandler struct {
+	w     io.Writer
+	mu    *sync.Mutex
+	level slog.Level
+	attrs []slog.Attr
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, record slog.Record) error {
+	var sb strings.Builder
+	if record.Level != slog.LevelInfo {
+		sb.WriteString(strings.ToLower(record.Level.String()) + ": ")
+	}
+	sb.WriteString(strings.ReplaceAll(record.Message, "_", " "))
+	write := func(attr slog.Attr) bool {
+		fmt.Fprintf(&sb, " %s=%s", attr.Key, attr.Value.Resolve())
+		return true
+	}
+	for _, attr := range h.attrs {
+		write(attr)
+	}
+	record.Attrs(write)
+	sb.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hh := *h
+	hh.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &hh
+}
+
+func (h *textHandler) WithGroup(string) slog.Handler {
+	return h
+}
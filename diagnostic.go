@@ -0,0 +1,252 @@
+package genx
+
+import (
+	"fmt"
+	"go/scanner"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Diagnostic is a problem found during the generation, the position is optional and refers to File.
+type Diagnostic struct {
+	Severity  Severity
+	Message   string
+	File      string
+	Line      int
+	Column    int
+	Extension string
+	// Target is the Config.Target of the run
+	Target string
+
+	// Err is the error the diagnostic is derived from, if any
+	Err error
+}
+
+// String renders the diagnostic compiler-style, e.g. prototype.graphql:12:3: error: message,
+// prefixed with the target in brackets if any.
+func (d *Diagnostic) String() string {
+	var sb strings.Builder
+	if d.Target != "" {
+		fmt.Fprintf(&sb, "[%s] ", d.Target)
+	}
+	if d.File != "" {
+		sb.WriteString(d.File)
+		if d.Line > 0 {
+			fmt.Fprintf(&sb, ":%d", d.Line)
+			if d.Column > 0 {
+				fmt.Fprintf(&sb, ":%d", d.Column)
+			}
+		}
+		sb.WriteString(": ")
+	}
+	sb.WriteString(string(d.Severity))
+	sb.WriteString(": ")
+	sb.WriteString(d.Message)
+	if d.Extension != "" {
+		fmt.Fprintf(&sb, " (%s)", d.Extension)
+	}
+	return sb.String()
+}
+
+func (d *Diagnostic) Error() string {
+	return d.String()
+}
+
+func (d *Diagnostic) Unwrap() error {
+	return d.Err
+}
+
+// Diagnostics is returned by Generate when it fails, it contains the warnings reported before the failure as well.
+type Diagnostics []*Diagnostic
+
+func (ds Diagnostics) Error() string {
+	lines := make([]string, len(ds))
+	for i, d := range ds {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (ds Diagnostics) Unwrap() []error {
+	errs := make([]error, len(ds))
+	for i, d := range ds {
+		errs[i] = d
+	}
+	return errs
+}
+
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// DiagnosticsFromError converts err into error diagnostics, keeping the source positions of
+// GraphQL and Go syntax errors, the context added by wrapping is kept as a prefix of the messages.
+func DiagnosticsFromError(err error) Diagnostics {
+	if err == nil {
+		return nil
+	}
+
+	var ds Diagnostics
+	if errors.As(err, &ds) {
+		return ds
+	}
+	// errors.Join and friends, but not the lists of positioned errors handled below
+	if multi, ok := err.(interface{ Unwrap() []error }); ok && !isPositionedList(err) {
+		for _, e := range multi.Unwrap() {
+			ds = append(ds, DiagnosticsFromError(e)...)
+		}
+		return ds
+	}
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		return Diagnostics{d}
+	}
+
+	var gqlErrs gqlerror.List
+	if errors.As(err, &gqlErrs) && len(gqlErrs) > 0 {
+		prefix := wrapPrefix(err, gqlErrs)
+		for _, e := range gqlErrs {
+			ds = append(ds, gqlDiagnostic(prefix, e, err))
+		}
+		return ds
+	}
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		return Diagnostics{gqlDiagnostic(wrapPrefix(err, gqlErr), gqlErr, err)}
+	}
+
+	var goErrs scanner.ErrorList
+	if errors.As(err, &goErrs) && len(goErrs) > 0 {
+		prefix := wrapPrefix(err, goErrs)
+		for _, e := range goErrs {
+			ds = append(ds, &Diagnostic{
+				Severity: SeverityError,
+				Message:  prefix + e.Msg,
+				File:     e.Pos.Filename,
+				Line:     e.Pos.Line,
+				Column:   e.Pos.Column,
+				Err:      err,
+			})
+		}
+		return ds
+	}
+
+	return Diagnostics{{Severity: SeverityError, Message: err.Error(), Err: err}}
+}
+
+func isPositionedList(err error) bool {
+	switch err.(type) {
+	case gqlerror.List, scanner.ErrorList:
+		return true
+	}
+	return false
+}
+
+// wrapPrefix returns the context added to inner by wrapping it into err.
+func wrapPrefix(err, inner error) string {
+	return strings.TrimSuffix(err.Error(), inner.Error())
+}
+
+func gqlDiagnostic(prefix string, e *gqlerror.Error, err error) *Diagnostic {
+	d := &Diagnostic{
+		Severity: SeverityError,
+		Message:  prefix + e.Message,
+		Err:      err,
+	}
+	if ps := e.Path.String(); ps != "" {
+		d.Message = prefix + ps + " " + e.Message
+	}
+	d.File, _ = e.Extensions["file"].(string)
+	if len(e.Locations) > 0 {
+		d.Line = e.Locations[0].Line
+		d.Column = e.Locations[0].Column
+	}
+	return d
+}
+
+// panicError converts the value recovered from a panic into an error with the stack of the panic.
+func panicError(p any) error {
+	if err, ok := p.(error); ok {
+		return errors.Wrap(err, "panic")
+	}
+	return errors.Errorf("panic: %v", p)
+}
+
+// errAborted stops the pipeline after a phase in which error diagnostics were reported.
+var errAborted = errors.New("aborted by the reported diagnostics")
+
+func (r *Runtime) checkDiagnostics() error {
+	if r.Diagnostics().HasErrors() {
+		return errAborted
+	}
+	return nil
+}
+
+// fail reports the diagnostics of err and returns every diagnostic of the run.
+func (r *Runtime) fail(err error) error {
+	if !errors.Is(err, errAborted) {
+		var extName string
+		var extErr *extensionError
+		if errors.As(err, &extErr) {
+			extName = extErr.name
+		}
+		for _, d := range DiagnosticsFromError(err) {
+			if d.Extension == "" {
+				d.Extension = extName
+			}
+			r.Report(d)
+		}
+	}
+	return r.Diagnostics()
+}
+
+type extensionError struct {
+	name string
+	err  error
+}
+
+func (e *extensionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *extensionError) Unwrap() error {
+	return e.err
+}
+
+// callExtension calls fn on behalf of ext, a panic inside fn is returned as an error.
+func callExtension(r *Runtime, ext Extension, fn func() error) (err error) {
+	r.mu.Lock()
+	r.extension = ext.Name()
+	phase := r.phase
+	r.mu.Unlock()
+	start := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			err = panicError(p)
+		}
+		r.Emit(&Event{Kind: EventExtensionFinished, Phase: phase, Duration: time.Since(start), Err: err})
+		if err != nil {
+			err = &extensionError{name: ext.Name(), err: err}
+		}
+		r.mu.Lock()
+		r.extension = ""
+		r.mu.Unlock()
+	}()
+	return fn()
+}
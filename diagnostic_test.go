@@ -0,0 +1,126 @@
+package genx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/extension/relayext"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panickingExtension struct {
+	genx.DefaultExtension
+}
+
+func (e *panickingExtension) Name() string {
+	return "panicking"
+}
+
+func (e *panickingExtension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result, error) {
+	panic("boom")
+}
+
+type reportingExtension struct {
+	genx.DefaultExtension
+	severity genx.Severity
+}
+
+func (e *reportingExtension) Name() string {
+	return "reporting"
+}
+
+func (e *reportingExtension) BeforeGenerate(ctx context.Context, r *genx.Runtime) error {
+	r.Report(&genx.Diagnostic{
+		Severity: e.severity,
+		Message:  "deprecated directive",
+		File:     "prototype.graphql",
+		Line:     1,
+		Column:   14,
+	})
+	return nil
+}
+
+func newPrototypeFS(t *testing.T, prototype string) *genx.MemFS {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte(prototype), 0o644))
+	return fsys
+}
+
+func TestDiagnosticsFromPanic(t *testing.T) {
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  newPrototypeFS(t, `type Query { hello: String }`),
+		PrototypeRelPattern: "prototype.graphql",
+	}, genx.Extensions(&panickingExtension{}))
+	var ds genx.Diagnostics
+	require.True(t, errors.As(err, &ds))
+	require.Len(t, ds, 1)
+	assert.Equal(t, genx.SeverityError, ds[0].Severity)
+	assert.Equal(t, "panicking", ds[0].Extension)
+	assert.Equal(t, "error: panic: boom (panicking)", ds[0].String())
+}
+
+func TestDiagnosticsFromSchema(t *testing.T) {
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  newPrototypeFS(t, "type Query {\n  hello: Unknown\n}\n"),
+		PrototypeRelPattern: "prototype.graphql",
+	}, genx.Extensions(&panickingExtension{}))
+	var ds genx.Diagnostics
+	require.True(t, errors.As(err, &ds))
+	require.Len(t, ds, 1)
+	assert.Equal(t, "prototype.graphql", ds[0].File)
+	assert.Equal(t, 2, ds[0].Line)
+	assert.Equal(t, 10, ds[0].Column)
+	assert.Equal(t, "prototype.graphql:2:10: error: failed to validate schema: Undefined type Unknown.", ds[0].String())
+}
+
+func TestDiagnosticsFromRelayext(t *testing.T) {
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  newPrototypeFS(t, "type User @node {\n  name: String!\n  address: Address\n}\n\ntype Address {\n  city: String!\n}\n"),
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(relayext.New()))
+	var ds genx.Diagnostics
+	require.True(t, errors.As(err, &ds))
+	require.Len(t, ds, 1)
+	assert.Equal(t, "prototype.graphql:3:3: error: unsupported type Address of field User.address (relayext)", ds[0].String())
+}
+
+func TestDiagnosticsFromFormat(t *testing.T) {
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  newPrototypeFS(t, `type Query { hello: String }`),
+		PrototypeRelPattern: "prototype.graphql",
+	}, genx.Extensions(&brokenExtension{}))
+	var ds genx.Diagnostics
+	require.True(t, errors.As(err, &ds))
+	require.Len(t, ds, 2)
+	assert.Equal(t, "a/broken.genx.go", ds[0].File)
+	assert.Equal(t, 2, ds[0].Line)
+	assert.Equal(t, "b/broken.genx.go", ds[1].File)
+}
+
+func TestDiagnosticsReported(t *testing.T) {
+	var reported []*genx.Diagnostic
+	config := func() *genx.Config {
+		return &genx.Config{
+			FS:                  newPrototypeFS(t, `type Query { hello: String }`),
+			PrototypeRelPattern: "prototype.graphql",
+			OnDiagnostic: func(d *genx.Diagnostic) {
+				reported = append(reported, d)
+			},
+		}
+	}
+
+	err := genx.Generate(context.Background(), config(), genx.Extensions(&reportingExtension{severity: genx.SeverityWarning}))
+	require.NoError(t, err)
+	require.Len(t, reported, 1)
+	assert.Equal(t, "prototype.graphql:1:14: warning: deprecated directive (reporting)", reported[0].String())
+
+	reported = nil
+	err = genx.Generate(context.Background(), config(), genx.Extensions(&reportingExtension{severity: genx.SeverityError}))
+	var ds genx.Diagnostics
+	require.True(t, errors.As(err, &ds))
+	assert.Equal(t, genx.Diagnostics(reported), ds)
+}
@@ -0,0 +1,116 @@
+package genx
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Phase is a step of the generation pipeline.
+type Phase string
+
+const (
+	PhaseBeforeGenerate Phase = "beforeGenerate"
+	PhaseLoadSchema     Phase = "loadSchema"
+	PhaseGenerate       Phase = "generate"
+	PhaseFormat         Phase = "format"
+	PhaseWrite          Phase = "write"
+	PhaseAfterGenerate  Phase = "afterGenerate"
+	PhaseCommit         Phase = "commit"
+)
+
+type EventKind string
+
+const (
+	EventPhaseStarted      EventKind = "phase_started"
+	EventPhaseFinished     EventKind = "phase_finished"
+	EventExtensionFinished EventKind = "extension_finished"
+	EventFileFormatted     EventKind = "file_formatted"
+	// EventFileWritten, EventFileUnchanged and EventFileDeleted are emitted once the outputs are committed
+	EventFileWritten   EventKind = "file_written"
+	EventFileUnchanged EventKind = "file_unchanged"
+	EventFileDeleted   EventKind = "file_deleted"
+)
+
+// Event describes the progress of a run, the fields that do not apply to the kind are empty.
+type Event struct {
+	Kind  EventKind
+	Phase Phase
+	// Extension is the extension being called, or the one that generated or removed the file
+	Extension string
+	// Target is the Config.Target of the run
+	Target   string
+	File     string
+	Duration time.Duration
+	Err      error
+}
+
+var eventLevels = map[EventKind]slog.Level{
+	EventPhaseStarted:      slog.LevelDebug,
+	EventPhaseFinished:     slog.LevelDebug,
+	EventExtensionFinished: slog.LevelDebug,
+	EventFileFormatted:     slog.LevelDebug,
+	EventFileWritten:       slog.LevelInfo,
+	EventFileUnchanged:     slog.LevelDebug,
+	EventFileDeleted:       slog.LevelInfo,
+}
+
+// Attrs returns the fields of the event which are set, but the target which the logger of the run carries.
+func (e *Event) Attrs() []slog.Attr {
+	var attrs []slog.Attr
+	if e.Phase != "" {
+		attrs = append(attrs, slog.String("phase", string(e.Phase)))
+	}
+	if e.Extension != "" {
+		attrs = append(attrs, slog.String("extension", e.Extension))
+	}
+	if e.File != "" {
+		attrs = append(attrs, slog.String("file", e.File))
+	}
+	if e.Duration > 0 {
+		attrs = append(attrs, slog.Duration("duration", e.Duration))
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.String("error", e.Err.Error()))
+	}
+	return attrs
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// Subscribe calls fn for every event emitted after it, the calls are serialized.
+func (r *Runtime) Subscribe(fn func(e *Event)) {
+	r.eventsMu.Lock()
+	defer r.eventsMu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Emit logs the event and passes it to the subscribers, Extension defaults to the extension being called.
+func (r *Runtime) Emit(e *Event) {
+	if e.Extension == "" {
+		r.mu.Lock()
+		e.Extension = r.extension
+		r.mu.Unlock()
+	}
+	e.Target = r.Target
+	r.Logger.LogAttrs(context.Background(), eventLevels[e.Kind], string(e.Kind), e.Attrs()...)
+
+	r.eventsMu.Lock()
+	defer r.eventsMu.Unlock()
+	for _, fn := range r.subscribers {
+		fn(e)
+	}
+}
+
+// runPhase runs fn as phase and emits its start and end.
+func (r *Runtime) runPhase(phase Phase, fn func() error) error {
+	r.mu.Lock()
+	r.phase = phase
+	r.mu.Unlock()
+	r.Emit(&Event{Kind: EventPhaseStarted, Phase: phase})
+	start := time.Now()
+	err := fn()
+	r.Emit(&Event{Kind: EventPhaseFinished, Phase: phase, Duration: time.Since(start), Err: err})
+	return err
+}
@@ -0,0 +1,68 @@
+package genx_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/extension/cleanup"
+	"github.com/molon/genx/extension/relayext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvents(t *testing.T) {
+	fsys := newPrototypeFS(t, "type User @node {\n  name: String!\n}\n")
+	require.NoError(t, fsys.WriteFile("server/resolver/post_resolver.genx.go", []byte("package resolver\n"), 0o644))
+
+	generate := func() ([]*genx.Event, string) {
+		var events []*genx.Event
+		var logs bytes.Buffer
+		err := genx.Generate(context.Background(), &genx.Config{
+			FS:                  fsys,
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/example/app",
+		}, genx.Extensions(relayext.New(), cleanup.New()),
+			genx.OnEvent(func(e *genx.Event) {
+				events = append(events, e)
+			}),
+			genx.Logger(slog.New(slog.NewTextHandler(&logs, nil))),
+		)
+		require.NoError(t, err)
+		return events, logs.String()
+	}
+
+	events, logs := generate()
+	var phases []genx.Phase
+	files := make(map[string]string)
+	for _, e := range events {
+		switch e.Kind {
+		case genx.EventPhaseStarted:
+			phases = append(phases, e.Phase)
+		case genx.EventPhaseFinished:
+			assert.NoError(t, e.Err)
+		case genx.EventExtensionFinished:
+			assert.NotEmpty(t, e.Extension)
+			assert.NotEmpty(t, e.Phase)
+		case genx.EventFileWritten, genx.EventFileDeleted, genx.EventFileUnchanged:
+			files[e.File] = string(e.Kind) + " by " + e.Extension
+		}
+	}
+	// relayext loads the schema itself
+	assert.Equal(t, []genx.Phase{
+		genx.PhaseBeforeGenerate, genx.PhaseGenerate, genx.PhaseFormat,
+		genx.PhaseWrite, genx.PhaseAfterGenerate, genx.PhaseCommit,
+	}, phases)
+	assert.Equal(t, "file_written by relayext", files["server/model/models.genx.go"])
+	assert.Equal(t, "file_deleted by cleanup", files["server/resolver/post_resolver.genx.go"])
+	assert.Contains(t, logs, "msg=file_deleted phase=commit extension=cleanup file=server/resolver/post_resolver.genx.go")
+	assert.Contains(t, logs, "msg=generated written=4 unchanged=0 deleted=1")
+
+	events, logs = generate()
+	for _, e := range events {
+		assert.NotEqual(t, genx.EventFileWritten, e.Kind, e.File)
+	}
+	assert.Contains(t, logs, "msg=generated written=0 unchanged=4 deleted=0")
+}
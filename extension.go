@@ -2,25 +2,119 @@ package genx
 
 import (
 	"context"
+	"log/slog"
+	"sync"
 
 	"github.com/vektah/gqlparser/v2/ast"
 )
 
 type Config struct {
-	OutputDir           string
+	// Target names the config in a run of several targets, it is set on the diagnostics and events of the config
+	Target    string
+	OutputDir string
+	// PrototypeRelPattern is a shorthand for a source of the prototype files in the output dir
 	PrototypeRelPattern string
-	GoModule            string
-	Extensions          []Extension
+	// Sources are read after PrototypeRelPattern, see LoadSources
+	Sources    []*SchemaSource
+	GoModule   string
+	Extensions []Extension
+
+	// FS is the file system the pipeline reads and writes through, defaults to the OS file system rooted at OutputDir
+	FS FS
+
+	// Formatters override the registered formatters by file extension for this run,
+	// a nil formatter leaves the files with that extension unformatted
+	Formatters map[string]Formatter
+
+	// Concurrency is the maximum number of files formatted or written at the same time, defaults to GOMAXPROCS
+	Concurrency int
+	// FormatCache is shared by the targets of a run, see GenerateTargets
+	FormatCache *FormatCache
+
+	// Incremental keeps a manifest of input and output hashes in ManifestRelPath, so that unchanged outputs
+	// are neither formatted nor written and the whole run is skipped when nothing changed
+	Incremental bool
+
+	// DryRun records every planned change into Changeset instead of touching OutputDir,
+	// Changeset is required then, see the DryRun option
+	DryRun    bool
+	Changeset *Changeset
+
+	// OnDiagnostic is called for every diagnostic as soon as it is reported
+	OnDiagnostic func(d *Diagnostic)
+
+	// Logger receives the events of the run and the logs of the extensions, defaults to discarding them
+	Logger *slog.Logger
+	// OnEvent is subscribed to the events of the run
+	OnEvent func(e *Event)
+}
+
+// outputFS returns FS, or the OS file system rooted at OutputDir if it is nil.
+func (c *Config) outputFS() FS {
+	if c.FS != nil {
+		return c.FS
+	}
+	return OSFS(c.OutputDir)
 }
 
 type Runtime struct {
 	*Config
+	// FS is the effective file system of this run, its writes are staged and only committed to Config.FS
+	// once every phase succeeded, or recorded into Changeset in dry run mode
+	FS      FS
 	Schema  *ast.Schema
 	Results map[string]*Result
+	// Logger is Config.Logger or a logger discarding everything
+	Logger *slog.Logger
+
+	manifest     *Manifest
+	sourceHashes map[string]string
+	unchanged    map[string]bool
+	// contributors are the names of the extensions whose fragments were merged into a file
+	contributors map[string][]string
+
+	mu          sync.Mutex
+	diagnostics Diagnostics
+	// extension is the name of the extension being called, it is attributed to the reported diagnostics
+	extension string
+	phase     Phase
+	services  map[string]*service
+	// stagedBy is the extension that staged the last write or removal of a file
+	stagedBy map[string]string
+
+	eventsMu    sync.Mutex
+	subscribers []func(e *Event)
+}
+
+// Report attaches a diagnostic to the run, an error diagnostic fails the generation after the current phase.
+func (r *Runtime) Report(d *Diagnostic) {
+	if d.Severity == "" {
+		d.Severity = SeverityError
+	}
+	r.mu.Lock()
+	if d.Extension == "" {
+		d.Extension = r.extension
+	}
+	if d.Target == "" {
+		d.Target = r.Target
+	}
+	r.diagnostics = append(r.diagnostics, d)
+	r.mu.Unlock()
+	if r.OnDiagnostic != nil {
+		r.OnDiagnostic(d)
+	}
+}
+
+// Diagnostics returns the diagnostics reported so far.
+func (r *Runtime) Diagnostics() Diagnostics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(Diagnostics(nil), r.diagnostics...)
 }
 
 type Result struct {
-	Files    []*File
+	Files []*File
+	// Metadata is free for the extension, prefer Provide to share typed data with other extensions
 	Metadata any
 }
 
@@ -2,9 +2,8 @@ package cleanup
 
 import (
 	"context"
-	"os"
-	"path/filepath"
-	"regexp"
+	"io/fs"
+	"path"
 
 	"github.com/molon/genx"
 	"github.com/pkg/errors"
@@ -24,39 +23,50 @@ func (e *Extension) Name() string {
 	return "cleanup"
 }
 
-var reGeneratedFileSuffix = regexp.MustCompile(`\.genx\.\w+$`)
+var _ genx.WithOrdering = (*Extension)(nil)
+
+func (e *Extension) RunsBefore() []string {
+	return nil
+}
+
+// RunsAfter makes sure the cleanup happens after every other extension has finished its work.
+func (e *Extension) RunsAfter() []string {
+	return []string{genx.AllExtensions}
+}
 
 func (e *Extension) AfterGenerate(ctx context.Context, r *genx.Runtime) error {
 	dirToFiles := make(map[string]map[string]bool)
 	for _, result := range r.Results {
 		for _, f := range result.Files {
-			dir := filepath.Dir(filepath.Join(r.OutputDir, f.RelPath))
+			name := genx.FSName(f.RelPath)
+			dir := path.Dir(name)
 			m, ok := dirToFiles[dir]
 			if !ok {
 				m = make(map[string]bool)
 				dirToFiles[dir] = m
 			}
-			m[filepath.Base(f.RelPath)] = true
+			m[path.Base(name)] = true
 		}
 	}
 
 	for dir, files := range dirToFiles {
-		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		err := fs.WalkDir(r.FS, dir, func(name string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
-			if info.IsDir() {
+			if d.IsDir() {
 				return nil
 			}
-			base := filepath.Base(path)
-			if !reGeneratedFileSuffix.MatchString(base) {
+			base := path.Base(name)
+			if !genx.IsGeneratedFile(base) {
 				return nil
 			}
 			if _, ok := files[base]; ok {
 				return nil
 			}
-			if err := os.Remove(path); err != nil {
-				return errors.Wrapf(err, "failed to remove %s", path)
+			r.Logger.Debug("removing generated file that is no longer generated", "extension", e.Name(), "file", name)
+			if err := r.FS.Remove(name); err != nil {
+				return errors.Wrapf(err, "failed to remove %s", name)
 			}
 			return nil
 		})
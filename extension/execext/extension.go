@@ -0,0 +1,212 @@
+// Package execext runs extensions written in any language as subprocesses,
+// see Request and Response for the messages exchanged over stdin and stdout.
+package execext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/molon/genx"
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+var _ genx.Extension = (*Extension)(nil)
+
+type Extension struct {
+	genx.DefaultExtension
+	name    string
+	command []string
+	options map[string]any
+
+	// Dir is the working directory of the command, defaults to the output dir
+	Dir string
+	// Env is appended to the environment of the command
+	Env []string
+	// Phases limits the phases the command is started for, defaults to all
+	Phases []Phase
+	// Dependencies are the extensions whose results the command consumes
+	Dependencies []string
+	Before       []string
+	After        []string
+}
+
+// New creates an extension that runs command with its arguments for every phase.
+func New(name string, command []string, options map[string]any) *Extension {
+	return &Extension{
+		name:    name,
+		command: command,
+		options: options,
+	}
+}
+
+func (e *Extension) Name() string {
+	return e.name
+}
+
+var _ genx.WithDependencies = (*Extension)(nil)
+
+func (e *Extension) DependsOn() []string {
+	return e.Dependencies
+}
+
+var _ genx.WithOrdering = (*Extension)(nil)
+
+func (e *Extension) RunsBefore() []string {
+	return e.Before
+}
+
+func (e *Extension) RunsAfter() []string {
+	return e.After
+}
+
+func (e *Extension) BeforeGenerate(ctx context.Context, r *genx.Runtime) error {
+	_, err := e.call(ctx, r, PhaseBeforeGenerate)
+	return err
+}
+
+func (e *Extension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result, error) {
+	resp, err := e.call(ctx, r, PhaseGenerate)
+	if err != nil || resp == nil {
+		return nil, err
+	}
+	result := &genx.Result{}
+	for _, f := range resp.Files {
+		if f.RelPath == "" {
+			return nil, errors.Errorf("%s returned a file without relPath", e.name)
+		}
+		result.Files = append(result.Files, &genx.File{RelPath: f.RelPath, Content: f.Content, Merge: f.Merge})
+	}
+	return result, nil
+}
+
+func (e *Extension) AfterGenerate(ctx context.Context, r *genx.Runtime) error {
+	_, err := e.call(ctx, r, PhaseAfterGenerate)
+	return err
+}
+
+func (e *Extension) call(ctx context.Context, r *genx.Runtime, phase Phase) (*Response, error) {
+	if len(e.Phases) > 0 && !slices.Contains(e.Phases, phase) {
+		return nil, nil
+	}
+	if len(e.command) == 0 {
+		return nil, errors.Errorf("no command for extension %s", e.name)
+	}
+
+	req, err := e.newRequest(r, phase)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.command[0], e.command[1:]...)
+	cmd.Dir = e.Dir
+	if cmd.Dir == "" {
+		cmd.Dir = r.OutputDir
+	}
+	if len(e.Env) > 0 {
+		cmd.Env = append(cmd.Environ(), e.Env...)
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.Wrapf(err, "%s %s failed: %s", e.name, phase, msg)
+		}
+		return nil, errors.Wrapf(err, "%s %s failed", e.name, phase)
+	}
+
+	// the command is free to log to stderr, diagnostics are only taken from the response
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			r.Logger.Info(line, "extension", e.name, "phase", phase)
+		}
+	}
+
+	resp := &Response{}
+	if len(bytes.TrimSpace(stdout.Bytes())) > 0 {
+		if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the %s response of %s", phase, e.name)
+		}
+	}
+	if phase != PhaseGenerate && len(resp.Files) > 0 {
+		return nil, errors.Errorf("%s returned files in the %s phase, files are only accepted in the %s phase", e.name, phase, PhaseGenerate)
+	}
+	for _, d := range resp.Diagnostics {
+		r.Report(&genx.Diagnostic{
+			Severity: d.Severity,
+			Message:  d.Message,
+			File:     d.File,
+			Line:     d.Line,
+			Column:   d.Column,
+		})
+	}
+	return resp, nil
+}
+
+func (e *Extension) newRequest(r *genx.Runtime, phase Phase) (*Request, error) {
+	sources, err := resolveSources(r)
+	if err != nil {
+		return nil, err
+	}
+	req := &Request{
+		Version: ProtocolVersion,
+		Phase:   phase,
+		Name:    e.name,
+		Config: Config{
+			OutputDir:           r.OutputDir,
+			PrototypeRelPattern: r.PrototypeRelPattern,
+			Sources:             sources,
+			GoModule:            r.GoModule,
+			DryRun:              r.DryRun,
+		},
+		Options: e.options,
+	}
+	if r.Schema != nil {
+		var sb strings.Builder
+		formatter.NewFormatter(&sb).FormatSchema(r.Schema)
+		req.Schema = sb.String()
+	}
+	if len(r.Results) > 0 {
+		req.Results = make(map[string]*Result, len(r.Results))
+		for name, result := range r.Results {
+			files := make([]*File, 0, len(result.Files))
+			for _, f := range result.Files {
+				files = append(files, &File{RelPath: f.RelPath, Content: f.Content, Merge: f.Merge})
+			}
+			req.Results[name] = &Result{Files: files}
+		}
+	}
+	return req, nil
+}
+
+func resolveSources(r *genx.Runtime) ([]*Source, error) {
+	var sources []*Source
+	for _, src := range r.SchemaSources() {
+		fsys := src.FS
+		if fsys == nil {
+			fsys = r.FS
+		}
+		files, err := src.Glob(fsys)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, &Source{
+			Patterns:      src.Patterns,
+			Excludes:      src.Excludes,
+			Introspection: src.Introspection,
+			Embedded:      src.FS != nil,
+			Files:         files,
+		})
+	}
+	return sources, nil
+}
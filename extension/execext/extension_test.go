@@ -0,0 +1,135 @@
+package execext_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/extension/execext"
+	"github.com/molon/genx/extension/relayext"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain lets the test binary act as an external extension when GENX_EXECEXT_HELPER is set.
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("GENX_EXECEXT_HELPER"); mode != "" {
+		os.Exit(serveHelper(mode))
+	}
+	os.Exit(m.Run())
+}
+
+func serveHelper(mode string) int {
+	req := &execext.Request{}
+	if err := json.NewDecoder(os.Stdin).Decode(req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	resp := &execext.Response{}
+	switch mode {
+	case "mocks":
+		if req.Phase != execext.PhaseGenerate {
+			return 0
+		}
+		var names []string
+		for _, file := range req.Results["relayext"].Files {
+			names = append(names, file.RelPath)
+		}
+		sort.Strings(names)
+		var sources []string
+		for _, src := range req.Config.Sources {
+			sources = append(sources, fmt.Sprintf("%v => %v", src.Patterns, src.Files))
+		}
+		content := fmt.Sprintf("// %s %v\n// sources: %s\n// schema has User: %t\n// relayext: %s\n",
+			req.Options["prefix"], req.Version, strings.Join(sources, ", "), strings.Contains(req.Schema, "type User"), strings.Join(names, ", "))
+		resp.Files = append(resp.Files, &execext.File{RelPath: "web/mocks.genx.ts", Content: content})
+		fmt.Fprintln(os.Stderr, "generated mocks")
+	case "diagnostics":
+		resp.Diagnostics = append(resp.Diagnostics, &execext.Diagnostic{
+			Message: "unsupported field", File: "prototype.graphql", Line: 2, Column: 3,
+		})
+	case "exit":
+		fmt.Fprintln(os.Stderr, "boom")
+		return 1
+	case "garbage":
+		fmt.Println("not json")
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		return 2
+	}
+	return 0
+}
+
+func newHelper(t *testing.T, mode string) *execext.Extension {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	ext := execext.New("mocks", []string{exe}, map[string]any{"prefix": "mocks"})
+	ext.Env = []string{"GENX_EXECEXT_HELPER=" + mode}
+	ext.Dependencies = []string{"relayext"}
+	return ext
+}
+
+func generate(t *testing.T, ext *execext.Extension) (*genx.MemFS, error) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(ext, relayext.New()))
+	return fsys, err
+}
+
+func TestExtension(t *testing.T) {
+	var diagnostics []string
+	var logs bytes.Buffer
+	ext := newHelper(t, "mocks")
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.MkdirAll("prototype", 0o755))
+	require.NoError(t, fsys.WriteFile("prototype/user.graphql", []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+	require.NoError(t, fsys.WriteFile("prototype/draft.graphql", []byte("type Draft\n"), 0o644))
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:       fsys,
+		Sources:  []*genx.SchemaSource{{Patterns: []string{"prototype/*.graphql"}, Excludes: []string{"prototype/draft.graphql"}}},
+		GoModule: "github.com/example/app",
+	}, genx.Extensions(ext, relayext.New()), genx.OnDiagnostic(func(d *genx.Diagnostic) {
+		diagnostics = append(diagnostics, d.String())
+	}), genx.Logger(slog.New(slog.NewTextHandler(&logs, nil))))
+	require.NoError(t, err)
+
+	content, err := fs.ReadFile(fsys, "web/mocks.genx.ts")
+	require.NoError(t, err)
+	assert.Equal(t, "// mocks 1\n// sources: [prototype/*.graphql] => [prototype/user.graphql]\n// schema has User: true\n"+
+		"// relayext: schema/schema.genx.graphql, server/model/models.genx.go, server/resolver/resolver.genx.go, server/resolver/user_resolver.genx.go\n", string(content))
+	// stderr is logged, not reported
+	assert.Empty(t, diagnostics)
+	assert.Contains(t, logs.String(), `msg="generated mocks" extension=mocks phase=generate`)
+}
+
+func TestExtensionDiagnostics(t *testing.T) {
+	_, err := generate(t, newHelper(t, "diagnostics"))
+	var ds genx.Diagnostics
+	require.True(t, errors.As(err, &ds), "%v", err)
+	require.Len(t, ds, 1)
+	assert.Equal(t, "prototype.graphql:2:3: error: unsupported field (mocks)", ds[0].String())
+
+	_, err = generate(t, newHelper(t, "exit"))
+	assert.ErrorContains(t, err, "mocks beforeGenerate failed: boom")
+
+	_, err = generate(t, newHelper(t, "garbage"))
+	assert.ErrorContains(t, err, "failed to decode the beforeGenerate response of mocks")
+
+	ext := newHelper(t, "exit")
+	ext.Phases = []execext.Phase{execext.PhaseGenerate}
+	_, err = generate(t, ext)
+	assert.ErrorContains(t, err, "mocks generate failed: boom")
+}
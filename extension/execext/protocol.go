@@ -0,0 +1,75 @@
+package execext
+
+import "github.com/molon/genx"
+
+// ProtocolVersion is sent with every request, it changes when the messages change incompatibly.
+const ProtocolVersion = 1
+
+// Phase is the lifecycle phase a request is sent for.
+type Phase string
+
+const (
+	PhaseBeforeGenerate Phase = "beforeGenerate"
+	PhaseGenerate       Phase = "generate"
+	PhaseAfterGenerate  Phase = "afterGenerate"
+)
+
+// Request is written as JSON to the stdin of the command, the command is started once per phase.
+type Request struct {
+	Version int    `json:"version"`
+	Phase   Phase  `json:"phase"`
+	Name    string `json:"name"`
+	Config  Config `json:"config"`
+	// Options are the options of the extension in the config file
+	Options map[string]any `json:"options,omitempty"`
+	// Schema is the SDL of the schema without the built-in definitions, it is empty before the schema is loaded
+	Schema string `json:"schema,omitempty"`
+	// Results are the files generated by the extensions that ran before, by extension name
+	Results map[string]*Result `json:"results,omitempty"`
+}
+
+type Config struct {
+	OutputDir           string `json:"outputDir,omitempty"`
+	PrototypeRelPattern string `json:"prototypeRelPattern"`
+	// Sources are the schema sources of the run including PrototypeRelPattern, resolved to their files
+	Sources  []*Source `json:"sources"`
+	GoModule string    `json:"goModule"`
+	DryRun   bool      `json:"dryRun,omitempty"`
+}
+
+// Source is a genx.SchemaSource with the files it selects.
+type Source struct {
+	Patterns      []string `json:"patterns"`
+	Excludes      []string `json:"excludes,omitempty"`
+	Introspection bool     `json:"introspection,omitempty"`
+	// Embedded sources are read from a genx.SchemaSource.FS, their files are not in the output dir
+	Embedded bool `json:"embedded,omitempty"`
+	// Files are the slash separated names of the selected files
+	Files []string `json:"files"`
+}
+
+// Response is read as JSON from the stdout of the command, an empty output is an empty response.
+type Response struct {
+	// Files are only accepted in the generate phase
+	Files       []*File       `json:"files,omitempty"`
+	Diagnostics []*Diagnostic `json:"diagnostics,omitempty"`
+}
+
+type Result struct {
+	Files []*File `json:"files"`
+}
+
+type File struct {
+	RelPath string             `json:"relPath"`
+	Content string             `json:"content"`
+	Merge   genx.MergeStrategy `json:"merge,omitempty"`
+}
+
+type Diagnostic struct {
+	// Severity defaults to error
+	Severity genx.Severity `json:"severity,omitempty"`
+	Message  string        `json:"message"`
+	File     string        `json:"file,omitempty"`
+	Line     int           `json:"line,omitempty"`
+	Column   int           `json:"column,omitempty"`
+}
@@ -2,8 +2,8 @@ package gosurgery
 
 import (
 	"context"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"regexp"
 	"strings"
 
@@ -30,20 +30,19 @@ func (e *Extension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result
 	genDirToFile := make(map[string][]*genx.File)
 	for _, result := range r.Results {
 		for _, v := range result.Files {
-			base := filepath.Base(v.RelPath)
-			if !strings.HasSuffix(base, ".genx.go") {
+			name := genx.FSName(v.RelPath)
+			if !strings.HasSuffix(path.Base(name), ".genx.go") {
 				continue
 			}
-			dir := filepath.Dir(v.RelPath)
+			dir := path.Dir(name)
 			genDirToFile[dir] = append(genDirToFile[dir], v)
 		}
 	}
 
 	userDirToFile := make(map[string][]*genx.File)
 	for dir := range genDirToFile {
-		dirRealPath := filepath.Join(r.OutputDir, dir)
-		userFiles, err := collectFiles(dirRealPath, func(info os.FileInfo) bool {
-			return strings.HasSuffix(info.Name(), ".go") && !strings.HasSuffix(info.Name(), ".genx.go")
+		userFiles, err := collectFiles(r.FS, dir, func(d fs.DirEntry) bool {
+			return strings.HasSuffix(d.Name(), ".go") && !strings.HasSuffix(d.Name(), ".genx.go")
 		})
 		if err != nil {
 			return nil, err
@@ -61,6 +60,27 @@ func (e *Extension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result
 	return &genx.Result{}, nil
 }
 
+var _ genx.WithOrdering = (*Extension)(nil)
+
+// RunsBefore returns nothing, extensions generating go files should declare that they run before gosurgery.
+func (e *Extension) RunsBefore() []string {
+	return nil
+}
+
+// RunsAfter returns the built-in extensions whose generated go files are operated on.
+func (e *Extension) RunsAfter() []string {
+	return []string{"relayext"}
+}
+
+var _ genx.Fingerprinter = (*Extension)(nil)
+
+// Fingerprint covers the user go files since the surgery depends on them.
+func (e *Extension) Fingerprint(_ context.Context, r *genx.Runtime) (string, error) {
+	return genx.HashFS(r.FS, func(name string) bool {
+		return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, ".genx.go")
+	})
+}
+
 var rePackageName = regexp.MustCompile(`package\s*(\w+)\s+`)
 
 // TODO: 上面的逻辑还需要考虑到一个文件夹下，多个 package name 的情况
@@ -72,21 +92,25 @@ func getPackageName(code string) string {
 	return ""
 }
 
-func collectFiles(dir string, filter func(os.FileInfo) bool) ([]*genx.File, error) {
+func collectFiles(fsys fs.FS, dir string, filter func(fs.DirEntry) bool) ([]*genx.File, error) {
 	var files []*genx.File
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
+			// nothing to collect before the first generation
+			if name == dir && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
 			return err
 		}
-		if info.IsDir() || (filter != nil && !filter(info)) {
+		if d.IsDir() || (filter != nil && !filter(d)) {
 			return nil
 		}
-		content, err := os.ReadFile(path)
+		content, err := fs.ReadFile(fsys, name)
 		if err != nil {
-			return errors.Wrapf(err, "read file %s", path)
+			return errors.Wrapf(err, "read file %s", name)
 		}
 		files = append(files, &genx.File{
-			RelPath: path,
+			RelPath: name,
 			Content: string(content),
 		})
 		return nil
@@ -430,7 +430,7 @@ func parseGoFiles(files []*genx.File) ([]*GoFile, error) {
 	var goFiles []*GoFile
 	for _, f := range files {
 		fset := token.NewFileSet()
-		af, err := parser.ParseFile(fset, "", f.Content, parser.ParseComments)
+		af, err := parser.ParseFile(fset, f.RelPath, f.Content, parser.ParseComments)
 		if err != nil {
 			return nil, errors.Wrapf(err, "parse file %s", f.RelPath)
 		}
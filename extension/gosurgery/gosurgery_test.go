@@ -10,6 +10,7 @@ import (
 	_ "embed"
 
 	"github.com/molon/genx"
+	"github.com/molon/genx/genxtest"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -34,9 +35,6 @@ func TestBuildIdentMap(t *testing.T) {
 	}
 }
 
-//go:embed testdata/fixed.genx.go
-var fixedGenxGo string
-
 func TestSurgery(t *testing.T) {
 	userSrc := `
 package main
@@ -91,7 +89,5 @@ func (u User[T, A]) HookList() {}
 	)
 	require.NoError(t, err)
 
-	if !assert.Equal(t, fixedGenxGo, generatedFiles[0].Content) {
-		t.Logf("actual result: \n%s", generatedFiles[0].Content)
-	}
+	genxtest.Golden(t, "testdata/fixed.genx.go", generatedFiles[0].Content)
 }
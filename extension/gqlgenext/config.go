@@ -0,0 +1,129 @@
+package gqlgenext
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/molon/genx/pkg/gqlx"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"gopkg.in/yaml.v3"
+
+	gqlconfig "github.com/99designs/gqlgen/codegen/config"
+)
+
+const configFilename = "gqlgen.yml"
+
+// readConfig reads gqlgen.yml without completing it, completing it would read the schema
+// from the working directory, so its paths are still relative to gqlgen.yml.
+func readConfig(fsys fs.FS) (*gqlconfig.Config, error) {
+	data, err := fs.ReadFile(fsys, configFilename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read gqlgen config")
+	}
+	conf := gqlconfig.DefaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(conf); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Wrap(err, "failed to parse gqlgen config")
+	}
+	return conf, nil
+}
+
+// configInputs are the files besides the Go packages gqlgen reads: gqlgen.yml, the schema files and the templates.
+func configInputs(fsys fs.FS, conf *gqlconfig.Config) ([]string, error) {
+	inputs := []string{configFilename}
+	for _, pattern := range conf.SchemaFilename {
+		pattern = path.Clean(pattern)
+		err := fs.WalkDir(fsys, gqlx.PatternDir(pattern), func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			ok, err := gqlx.MatchPath(pattern, name)
+			if ok {
+				inputs = append(inputs, name)
+			}
+			return err
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.Wrapf(err, "failed to glob schema %s", pattern)
+		}
+	}
+	for _, name := range []string{conf.Model.ModelTemplate, conf.Resolver.ResolverTemplate} {
+		if name != "" {
+			inputs = append(inputs, path.Clean(name))
+		}
+	}
+	return lo.Uniq(inputs), nil
+}
+
+// outputPatterns are the files gqlgen writes as path.Match patterns.
+func outputPatterns(conf *gqlconfig.Config) []string {
+	var patterns []string
+	if conf.Exec.Layout == gqlconfig.ExecLayoutFollowSchema {
+		tmpl := lo.CoalesceOrEmpty(conf.Exec.FilenameTemplate, "{name}.generated.go")
+		patterns = append(patterns,
+			path.Join(conf.Exec.DirName, strings.ReplaceAll(tmpl, "{name}", "*")),
+			path.Join(conf.Exec.DirName, "root_.generated.go"),
+		)
+	} else {
+		patterns = append(patterns, conf.Exec.Filename)
+	}
+	patterns = append(patterns, conf.Model.Filename, conf.Federation.Filename)
+	if conf.Resolver.Layout == gqlconfig.LayoutFollowSchema {
+		tmpl := lo.CoalesceOrEmpty(conf.Resolver.FilenameTemplate, "{name}.resolvers.go")
+		patterns = append(patterns,
+			lo.CoalesceOrEmpty(conf.Resolver.Filename, path.Join(conf.Resolver.DirName, "resolver.go")),
+			path.Join(conf.Resolver.DirName, strings.ReplaceAll(tmpl, "{name}", "*")),
+		)
+	} else {
+		patterns = append(patterns, conf.Resolver.Filename)
+	}
+	return lo.Uniq(lo.FilterMap(patterns, func(p string, _ int) (string, bool) {
+		return path.Clean(p), p != ""
+	}))
+}
+
+// packageRefs are the import paths of the packages gqlgen binds to, by gqlgen.yml or @goModel in the schema.
+func packageRefs(conf *gqlconfig.Config, schema []*ast.Source) []string {
+	var models []string
+	for _, entry := range conf.Models {
+		models = append(models, entry.Model...)
+	}
+	// a schema which does not parse is reported by gqlgen
+	if sd, err := parser.ParseSchemas(schema...); err == nil {
+		for _, def := range append(sd.Definitions, sd.Extensions...) {
+			directive := def.Directives.ForName("goModel")
+			if directive == nil {
+				continue
+			}
+			for _, arg := range directive.Arguments {
+				switch {
+				case arg.Name == "model" && arg.Value.Kind == ast.StringValue:
+					models = append(models, arg.Value.Raw)
+				case arg.Name == "models" && arg.Value.Kind == ast.ListValue:
+					for _, child := range arg.Value.Children {
+						models = append(models, child.Value.Raw)
+					}
+				}
+			}
+		}
+	}
+
+	refs := append([]string{}, conf.AutoBind...)
+	for _, model := range models {
+		// a model is the import path followed by the type name, e.g. github.com/example/app/model.User
+		if i := strings.LastIndex(model, "."); i > strings.LastIndex(model, "/") {
+			refs = append(refs, model[:i])
+		}
+	}
+	return lo.Uniq(refs)
+}
@@ -2,6 +2,10 @@ package gqlgenext
 
 import (
 	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/99designs/gqlgen/plugin"
 
@@ -12,10 +16,6 @@ import (
 	gqlconfig "github.com/99designs/gqlgen/codegen/config"
 )
 
-type WithGQLPlugins interface {
-	GQLPlugins() []plugin.Plugin
-}
-
 var _ genx.Extension = (*Extension)(nil)
 
 type Extension struct {
@@ -31,24 +31,74 @@ func (e *Extension) Name() string {
 	return "gqlgenext"
 }
 
-func (e *Extension) AfterGenerate(ctx context.Context, r *genx.Runtime) error {
-	gqlconf, err := gqlconfig.LoadConfig("gqlgen.yml")
-	if err != nil {
-		return errors.Wrap(err, "failed to load gqlgen config")
-	}
+// ProvidePlugins adds plugins of ext to the gqlgen run of gqlgenext, which runs after the Generate phase.
+func ProvidePlugins(r *genx.Runtime, ext genx.Extension, plugins ...plugin.Plugin) error {
+	return genx.Provide(r, pluginsKey(ext.Name()), plugins)
+}
 
+// pluginsKey is the key of the plugins of an extension, since a key can only be provided by one extension.
+func pluginsKey(name string) string {
+	return "gqlgenext.plugins." + name
+}
+
+func (e *Extension) AfterGenerate(ctx context.Context, r *genx.Runtime) error {
 	var options []gqlapi.Option
 	for _, ext := range r.Config.Extensions {
-		if ext == e {
+		plugins, err := genx.Lookup[[]plugin.Plugin](r, pluginsKey(ext.Name()))
+		if errors.Is(err, genx.ErrNotProvided) {
 			continue
 		}
-		if withGQLPlugins, ok := ext.(WithGQLPlugins); ok {
-			for _, p := range withGQLPlugins.GQLPlugins() {
-				options = append(options, gqlapi.AddPlugin(p))
-			}
+		if err != nil {
+			return err
+		}
+		for _, p := range plugins {
+			options = append(options, gqlapi.AddPlugin(p))
 		}
 	}
 
+	start := time.Now()
+	defer func() {
+		r.Logger.Debug("gqlgen finished", "extension", e.Name(), "duration", time.Since(start))
+	}()
+	// gqlgen has to see the staged files, so it runs in a scratch dir even for an output dir on the OS file system,
+	// whose location is still needed to find a module rooted above it and to resolve the relative replace directives
+	var dir string
+	if dirFS, ok := r.FS.(genx.DirFS); ok {
+		dir = dirFS.Dir()
+	}
+	return generateInScratch(r.FS, dir, options)
+}
+
+var _ genx.Fingerprinter = (*Extension)(nil)
+
+// Fingerprint covers the gqlgen config and all go files since gqlgen binds to them.
+func (e *Extension) Fingerprint(_ context.Context, r *genx.Runtime) (string, error) {
+	return genx.HashFS(r.FS, func(name string) bool {
+		return name == "gqlgen.yml" || name == "go.mod" || strings.HasSuffix(name, ".go")
+	})
+}
+
+// gqlgen resolves every path relative to the working directory which is process wide
+var chdirMu sync.Mutex
+
+func generate(dir string, options []gqlapi.Option) error {
+	chdirMu.Lock()
+	defer chdirMu.Unlock()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return errors.Wrap(err, "failed to get working directory")
+	}
+	if err := os.Chdir(dir); err != nil {
+		return errors.Wrapf(err, "failed to change working directory to %s", dir)
+	}
+	defer os.Chdir(wd)
+
+	gqlconf, err := gqlconfig.LoadConfig(configFilename)
+	if err != nil {
+		return errors.Wrap(err, "failed to load gqlgen config")
+	}
+
 	if err := gqlapi.Generate(gqlconf, options...); err != nil {
 		return errors.Wrap(err, "failed to generate gqlgen")
 	}
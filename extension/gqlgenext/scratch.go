@@ -0,0 +1,352 @@
+package gqlgenext
+
+import (
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/molon/genx"
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/mod/modfile"
+
+	gqlapi "github.com/99designs/gqlgen/api"
+	gqlconfig "github.com/99designs/gqlgen/codegen/config"
+)
+
+// generateInScratch runs gqlgen in a scratch directory, because gqlgen can only work on the OS file system,
+// and writes back the outputs gqlgen changed there. Only the inputs of gqlgen are copied: go.mod and go.sum,
+// gqlgen.yml, the schema files and the Go packages of the module gqlgen binds to or writes, with their imports.
+// dir is the location of fsys if any, the module may be rooted in a parent of it.
+func generateInScratch(fsys genx.FS, dir string, options []gqlapi.Option) error {
+	conf, err := readConfig(fsys)
+	if err != nil {
+		return err
+	}
+	mod, err := findModule(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	scratchDir, err := os.MkdirTemp("", "genx-gqlgenext-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create scratch dir")
+	}
+	defer os.RemoveAll(scratchDir)
+	scratch := genx.OSFS(scratchDir)
+	// gqlgen runs in the scratch counterpart of fsys
+	work := genx.OSFS(filepath.Join(scratchDir, filepath.FromSlash(mod.rel)))
+
+	if err := copyInputs(scratch, mod, conf); err != nil {
+		return errors.Wrap(err, "failed to populate scratch dir")
+	}
+	if mod.dir != "" {
+		if err := absoluteReplaces(scratch, mod.dir); err != nil {
+			return err
+		}
+	}
+
+	outputs := outputPatterns(conf)
+	before, err := snapshot(work, outputs)
+	if err != nil {
+		return err
+	}
+
+	if err := generate(work.Dir(), options); err != nil {
+		return err
+	}
+
+	after, err := snapshot(work, outputs)
+	if err != nil {
+		return err
+	}
+	for name, content := range after {
+		if prev, ok := before[name]; ok && prev == content {
+			continue
+		}
+		if err := fsys.MkdirAll(path.Dir(name), os.ModePerm); err != nil {
+			return errors.Wrapf(err, "failed to create directory %s", path.Dir(name))
+		}
+		if err := fsys.WriteFile(name, []byte(content), 0o644); err != nil {
+			return errors.Wrapf(err, "failed to write file %s", name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; ok {
+			continue
+		}
+		if err := fsys.Remove(name); err != nil {
+			return errors.Wrapf(err, "failed to remove %s", name)
+		}
+	}
+	return nil
+}
+
+// module is the Go module gqlgen runs in.
+type module struct {
+	// path is the module path, empty without a go.mod
+	path string
+	// dir is the root directory of the module on the OS file system, empty when fsys is not on it
+	dir string
+	// rel is the slash separated location of fsys in the module
+	rel  string
+	fsys fs.FS
+}
+
+// findModule finds the go.mod at the root of fsys, or in the parents of dir on the OS file system.
+func findModule(fsys fs.FS, dir string) (*module, error) {
+	mod := &module{dir: dir, rel: ".", fsys: fsys}
+	data, err := fs.ReadFile(fsys, "go.mod")
+	if errors.Is(err, fs.ErrNotExist) && dir != "" {
+		mod.dir, mod.rel, data, err = findModuleRoot(dir)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		// gqlgen reports the missing module
+		return &module{dir: dir, rel: ".", fsys: fsys}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read go.mod")
+	}
+	mod.path = modfile.ModulePath(data)
+	return mod, nil
+}
+
+// findModuleRoot returns the closest parent of dir with a go.mod, the location of dir in it and the go.mod.
+func findModuleRoot(dir string) (string, string, []byte, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", nil, err
+	}
+	for root := filepath.Dir(abs); ; root = filepath.Dir(root) {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			rel, err := filepath.Rel(root, abs)
+			return root, filepath.ToSlash(rel), data, err
+		}
+		if !errors.Is(err, fs.ErrNotExist) || filepath.Dir(root) == root {
+			return "", "", nil, err
+		}
+	}
+}
+
+// open returns the file system holding name, a slash separated path in the module, and its path there.
+// The files of fsys are read from it since it holds the staged files.
+func (m *module) open(name string) (fs.FS, string, bool) {
+	if rel, ok := relPath(m.rel, name); ok {
+		return m.fsys, rel, true
+	}
+	if m.dir == "" {
+		return nil, "", false
+	}
+	return os.DirFS(m.dir), name, true
+}
+
+// packageDir returns the directory of a package of the module by its import path.
+func (m *module) packageDir(importPath string) (string, bool) {
+	if m.path == "" {
+		return "", false
+	}
+	if importPath == m.path {
+		return ".", true
+	}
+	rel, ok := strings.CutPrefix(importPath, m.path+"/")
+	return rel, ok
+}
+
+// relPath returns name relative to dir if it is inside of it, both are slash separated and clean.
+func relPath(dir, name string) (string, bool) {
+	if dir == "." {
+		return name, !strings.HasPrefix(name, "../") && name != ".."
+	}
+	if name == dir {
+		return ".", true
+	}
+	rel, ok := strings.CutPrefix(name, dir+"/")
+	return rel, ok
+}
+
+// copyInputs copies the inputs of gqlgen from the module into scratch, which mirrors the module.
+func copyInputs(scratch genx.FS, mod *module, conf *gqlconfig.Config) error {
+	copyFile := func(name string) (string, error) {
+		src, srcName, ok := mod.open(name)
+		if !ok {
+			return "", errors.Errorf("%s is outside of the module", name)
+		}
+		data, err := fs.ReadFile(src, srcName)
+		if err != nil {
+			return "", err
+		}
+		if err := scratch.MkdirAll(path.Dir(name), os.ModePerm); err != nil {
+			return "", err
+		}
+		return string(data), scratch.WriteFile(name, data, 0o644)
+	}
+
+	for _, name := range []string{"go.mod", "go.sum"} {
+		if _, err := copyFile(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	inputs, err := configInputs(mod.fsys, conf)
+	if err != nil {
+		return err
+	}
+	var schema []*ast.Source
+	for _, name := range inputs {
+		content, err := copyFile(path.Join(mod.rel, name))
+		if err != nil {
+			return err
+		}
+		if name != configFilename {
+			schema = append(schema, &ast.Source{Name: name, Input: content})
+		}
+	}
+
+	// the packages gqlgen writes and binds to, followed by their imports in the module
+	var queue []string
+	for _, pattern := range outputPatterns(conf) {
+		queue = append(queue, path.Join(mod.rel, path.Dir(pattern)))
+	}
+	for _, ref := range packageRefs(conf, schema) {
+		if dir, ok := mod.packageDir(ref); ok {
+			queue = append(queue, dir)
+		}
+	}
+	copied := make(map[string]bool)
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		if copied[dir] {
+			continue
+		}
+		copied[dir] = true
+
+		src, srcDir, ok := mod.open(dir)
+		if !ok {
+			return errors.Errorf("package %s is outside of the module", dir)
+		}
+		entries, err := fs.ReadDir(src, srcDir)
+		if errors.Is(err, fs.ErrNotExist) {
+			// an output package gqlgen creates
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			name := path.Join(dir, entry.Name())
+			content, err := copyFile(name)
+			if err != nil {
+				return err
+			}
+			if !strings.HasSuffix(name, ".go") {
+				continue
+			}
+			f, err := parser.ParseFile(token.NewFileSet(), name, content, parser.ImportsOnly)
+			if err != nil {
+				// gqlgen reports the broken file
+				continue
+			}
+			for _, spec := range f.Imports {
+				importPath, err := strconv.Unquote(spec.Path.Value)
+				if err != nil {
+					continue
+				}
+				if dir, ok := mod.packageDir(importPath); ok {
+					queue = append(queue, dir)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// snapshot reads the files of fsys matching the patterns.
+func snapshot(fsys fs.FS, patterns []string) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, pattern := range patterns {
+		if _, ok := relPath(".", pattern); !ok {
+			return nil, errors.Errorf("gqlgen output %s is outside of the output dir", pattern)
+		}
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to glob gqlgen output %s", pattern)
+		}
+		for _, name := range matches {
+			if info, err := fs.Stat(fsys, name); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			content, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to snapshot gqlgen outputs")
+			}
+			files[name] = string(content)
+		}
+	}
+	return files, nil
+}
+
+// absoluteReplaces points the relative replace directives of the go.mod in scratch to the modules next to dir,
+// the root of the module.
+func absoluteReplaces(scratch genx.FS, dir string) error {
+	data, err := fs.ReadFile(scratch, "go.mod")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read go.mod")
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve %s", dir)
+	}
+	data, err = rewriteReplaces(data, func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, filepath.FromSlash(p))
+	})
+	if err != nil {
+		return err
+	}
+	if err := scratch.WriteFile("go.mod", data, 0o644); err != nil {
+		return errors.Wrap(err, "failed to write go.mod")
+	}
+	return nil
+}
+
+// rewriteReplaces maps the directory paths of the replace directives of a go.mod with fn.
+func rewriteReplaces(data []byte, fn func(p string) string) ([]byte, error) {
+	f, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse go.mod")
+	}
+	changed := false
+	for _, r := range slices.Clone(f.Replace) {
+		if r.New.Version != "" {
+			continue
+		}
+		p := fn(r.New.Path)
+		if p == r.New.Path {
+			continue
+		}
+		if err := f.AddReplace(r.Old.Path, r.Old.Version, p, ""); err != nil {
+			return nil, errors.Wrapf(err, "failed to replace %s", r.Old.Path)
+		}
+		changed = true
+	}
+	if !changed {
+		return data, nil
+	}
+	f.Cleanup()
+	return f.Format()
+}
@@ -0,0 +1,118 @@
+package gqlgenext
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbsoluteReplaces(t *testing.T) {
+	goMod := `module github.com/example/app
+
+go 1.23.0
+
+require github.com/molon/genx v0.0.0
+
+replace github.com/molon/genx => ../../
+
+replace github.com/example/lib => github.com/fork/lib v1.0.0
+`
+	scratch := genx.NewMemFS()
+	require.NoError(t, scratch.WriteFile("go.mod", []byte(goMod), 0o644))
+
+	dir := filepath.Join(t.TempDir(), "services", "app")
+	require.NoError(t, absoluteReplaces(scratch, dir))
+
+	data, err := fs.ReadFile(scratch, "go.mod")
+	require.NoError(t, err)
+	abs := filepath.Dir(filepath.Dir(dir))
+	assert.Contains(t, string(data), "replace github.com/molon/genx => "+abs+"\n")
+	assert.Contains(t, string(data), "replace github.com/example/lib => github.com/fork/lib v1.0.0\n")
+}
+
+func TestCopyInputs(t *testing.T) {
+	// the output dir is a service of a module rooted above it
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":                      "module github.com/example/app\n\ngo 1.23.0\n",
+		"go.sum":                      "",
+		"pkg/scalar/scalar.go":        "package scalar\n",
+		"pkg/unused/unused.go":        "package unused\n",
+		"services/users/README.md":    "users\n",
+		"services/users/docs/api.txt": "api\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+	}
+	dir := filepath.Join(root, "services", "users")
+	fsys := genx.NewMemFS()
+	for name, content := range map[string]string{
+		"gqlgen.yml": `schema:
+  - schema/*.graphql
+exec:
+  layout: follow-schema
+  dir: server/exec
+model:
+  filename: server/model/models.gqlgen.go
+resolver:
+  layout: follow-schema
+  dir: server
+  filename: server/gqlresolver.go
+  filename_template: "{name}.gqlresolver.go"
+autobind:
+  - github.com/example/app/services/users/server/model
+`,
+		"schema/schema.graphql":          "type User @goModel(model: \"github.com/example/app/pkg/scalar.User\") {\n  name: String!\n}\n",
+		"schema/notes.txt":               "notes\n",
+		"server/model/models.genx.go":    "package model\n",
+		"server/resolver/resolver.go":    "package resolver\n\nimport _ \"github.com/example/app/services/users/server/model\"\n",
+		"server/server.go":               "package server\n\nimport _ \"github.com/example/app/services/users/server/resolver\"\n",
+		"server/exec/root_.generated.go": "package exec\n",
+		"web/index.html":                 "<html></html>\n",
+	} {
+		require.NoError(t, fsys.MkdirAll(filepath.Dir(name), os.ModePerm))
+		require.NoError(t, fsys.WriteFile(name, []byte(content), 0o644))
+	}
+
+	mod, err := findModule(fsys, dir)
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/example/app", mod.path)
+	assert.Equal(t, root, mod.dir)
+	assert.Equal(t, "services/users", mod.rel)
+
+	conf, err := readConfig(fsys)
+	require.NoError(t, err)
+	scratch := genx.NewMemFS()
+	require.NoError(t, copyInputs(scratch, mod, conf))
+	var copied []string
+	require.NoError(t, fs.WalkDir(scratch, ".", func(name string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			copied = append(copied, name)
+		}
+		return err
+	}))
+	assert.ElementsMatch(t, []string{
+		"go.mod",
+		"go.sum",
+		"pkg/scalar/scalar.go",
+		"services/users/gqlgen.yml",
+		"services/users/schema/schema.graphql",
+		"services/users/server/exec/root_.generated.go",
+		"services/users/server/model/models.genx.go",
+		"services/users/server/resolver/resolver.go",
+		"services/users/server/server.go",
+	}, copied)
+
+	assert.Equal(t, []string{
+		"server/exec/*.generated.go",
+		"server/exec/root_.generated.go",
+		"server/model/models.gqlgen.go",
+		"server/gqlresolver.go",
+		"server/*.gqlresolver.go",
+	}, outputPatterns(conf))
+}
@@ -2,9 +2,11 @@ package relayext
 
 import (
 	"context"
-	"path/filepath"
+	"io/fs"
+	"text/template"
 
 	"github.com/molon/genx"
+	"github.com/molon/genx/extension/gqlgenext"
 	"github.com/molon/genx/pkg/gqlx"
 	"github.com/pkg/errors"
 	"github.com/vektah/gqlparser/v2"
@@ -14,19 +16,42 @@ import (
 
 const (
 	header = "Code generated by github.com/molon/genx/extension/relayext. DO NOT EDIT.\n\n"
+
+	// DataKey is the key of the *Data provided to the extensions running after relayext
+	DataKey = "relayext.data"
 )
 
+// LookupData returns the nodes parsed by relayext, for extensions declaring relayext as a dependency.
+func LookupData(r *genx.Runtime) (*Data, error) {
+	return genx.Lookup[*Data](r, DataKey)
+}
+
 var _ genx.Extension = (*Extension)(nil)
 
 type Extension struct {
 	genx.DefaultExtension
-	isNodeType      func(nodeName string) bool
-	generatedFiles  []*genx.File
-	gqlResolverImpl *gqlResolverImplementer
+	isNodeType     func(nodeName string) bool
+	inverses       map[string]map[string]string
+	joins          map[string]map[string]*Join
+	generatedFiles []*genx.File
+
+	layout         *Layout
+	globalIDFormat GlobalIDFormat
+	// resolvedLayout is layout completed with the defaults during the run
+	resolvedLayout *Layout
+
+	templateDir string
+	templateFS  fs.FS
+	funcs       template.FuncMap
+	templates   map[string]*template.Template
 }
 
-func New() *Extension {
-	return &Extension{}
+func New(opts ...Option) *Extension {
+	e := &Extension{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 func (e *Extension) Name() string {
@@ -34,15 +59,26 @@ func (e *Extension) Name() string {
 }
 
 func (e *Extension) BeforeGenerate(ctx context.Context, r *genx.Runtime) error {
-	prototypePattern := filepath.Join(r.OutputDir, r.PrototypeRelPattern)
-	sources, err := gqlx.LoadSources(prototypePattern)
+	// the extension may be reused across runs, e.g. by watch
+	e.generatedFiles = nil
+
+	sources, err := r.LoadSources()
 	if err != nil {
 		return err
 	}
 
 	sd, err := parser.ParseSchemas(sources...)
 	if err != nil {
-		return errors.Wrapf(err, "failed to parse prototype file %s", prototypePattern)
+		return errors.Wrap(err, "failed to parse prototype files")
+	}
+
+	e.resolvedLayout, err = e.layout.resolve(r.GoModule, e.templateFuncs())
+	if err != nil {
+		return err
+	}
+	e.globalIDFormat, err = e.globalIDFormat.resolve()
+	if err != nil {
+		return err
 	}
 
 	result, err := enhanceSchema(ctx, sd)
@@ -54,11 +90,13 @@ func (e *Extension) BeforeGenerate(ctx context.Context, r *genx.Runtime) error {
 		_, ok := result.Nodes[nodeName]
 		return ok
 	}
+	e.inverses = result.Inverses
+	e.joins = result.Joins
 
-	schemaFile := "schema/schema.genx.graphql"
+	schemaFile := e.resolvedLayout.SchemaFile
 	schemaBody := gqlx.FormatDocument(sd)
 	schemaBody = "# " + header + schemaBody
-	schema, err := gqlparser.LoadSchema(&ast.Source{Name: filepath.Base(schemaFile), Input: schemaBody})
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: schemaFile, Input: schemaBody})
 	if err != nil {
 		return errors.Wrap(err, "failed to validate schema")
 	}
@@ -67,6 +105,7 @@ func (e *Extension) BeforeGenerate(ctx context.Context, r *genx.Runtime) error {
 	e.generatedFiles = append(e.generatedFiles, &genx.File{
 		RelPath: schemaFile,
 		Content: schemaBody,
+		Merge:   genx.MergeGraphQL,
 	})
 
 	return nil
@@ -74,17 +113,31 @@ func (e *Extension) BeforeGenerate(ctx context.Context, r *genx.Runtime) error {
 
 func (e *Extension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result, error) {
 	// TODO: 这个机制主要是因为在先前 node 指令已经被删除了，但是现在感觉虽然是生成时指令，但还是应该保留下来，因为这个指令是用来标记 node 类型，有它的意义在，记得调整
-	data := NewData(r, func(def *ast.Definition) bool {
+	data, err := newData(r, func(def *ast.Definition) bool {
 		return e.isNodeType(def.Name) && def.Kind == ast.Object
-	})
+	}, e.inverses, e.joins)
+	if err != nil {
+		return nil, err
+	}
+	data.Layout = e.resolvedLayout
+	data.GlobalIDFormat = e.globalIDFormat
+	if err := genx.Provide(r, DataKey, data); err != nil {
+		return nil, err
+	}
+
+	templates, err := e.loadTemplates(r)
+	if err != nil {
+		return nil, err
+	}
+	e.templates = templates
 
 	generatedFiles, err := e.generate(ctx, data)
 	if err != nil {
 		return nil, err
 	}
 
-	e.gqlResolverImpl = &gqlResolverImplementer{
-		data: data,
+	if err := gqlgenext.ProvidePlugins(r, e, &gqlResolverImplementer{data: data}); err != nil {
+		return nil, err
 	}
 	return &genx.Result{
 		Files: append(e.generatedFiles, generatedFiles...),
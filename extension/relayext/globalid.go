@@ -0,0 +1,32 @@
+package relayext
+
+import (
+	"github.com/pkg/errors"
+)
+
+// GlobalIDFormat is the format of the ids of the nodes, which encode their types for the node queries.
+type GlobalIDFormat string
+
+const (
+	// GlobalIDBase64 ids are the opaque base64 of Type:xid.
+	GlobalIDBase64 GlobalIDFormat = "base64"
+	// GlobalIDPrefixed ids are xids prefixed with the snake cased type, e.g. user_profile_csv1l6ht6ta6f8trmvhg.
+	GlobalIDPrefixed GlobalIDFormat = "prefixed"
+)
+
+// WithGlobalIDFormat sets the format of the ids of the nodes, GlobalIDBase64 by default.
+func WithGlobalIDFormat(format GlobalIDFormat) Option {
+	return func(e *Extension) {
+		e.globalIDFormat = format
+	}
+}
+
+func (f GlobalIDFormat) resolve() (GlobalIDFormat, error) {
+	switch f {
+	case "":
+		return GlobalIDBase64, nil
+	case GlobalIDBase64, GlobalIDPrefixed:
+		return f, nil
+	}
+	return "", errors.Errorf("unknown global id format %s", f)
+}
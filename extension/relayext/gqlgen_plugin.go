@@ -10,17 +10,10 @@ import (
 	"github.com/99designs/gqlgen/codegen"
 	"github.com/99designs/gqlgen/plugin"
 	"github.com/molon/genx"
-	"github.com/molon/genx/extension/gqlgenext"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 )
 
-var _ gqlgenext.WithGQLPlugins = (*Extension)(nil)
-
-func (e *Extension) GQLPlugins() []plugin.Plugin {
-	return []plugin.Plugin{e.gqlResolverImpl}
-}
-
 var (
 	_ plugin.ResolverImplementer = (*gqlResolverImplementer)(nil)
 	_ plugin.CodeGenerator       = (*gqlResolverImplementer)(nil)
@@ -43,6 +36,9 @@ func (i *gqlResolverImplementer) Implement(body string, field *codegen.Field) st
 			if len(field.Args) != 1 {
 				return body
 			}
+			if node, method := i.joinMutation(field.Name); node != nil {
+				return fmt.Sprintf("return r.Resolver.%s.%s(ctx, %s)", node.Name, method, field.Args[0].VarName)
+			}
 			matches := reMutation.FindStringSubmatch(field.Name)
 			if len(matches) <= 2 {
 				return body
@@ -67,6 +63,14 @@ func (i *gqlResolverImplementer) Implement(body string, field *codegen.Field) st
 			if typRef == nil {
 				return body
 			}
+			if typRef.Definition.Name == nodeInterface && len(field.Args) == 1 {
+				switch field.Name {
+				case "node":
+					return fmt.Sprintf("return r.Resolver.Node(ctx, %s)", field.Args[0].VarName)
+				case "nodes":
+					return fmt.Sprintf("return r.Resolver.Nodes(ctx, %s)", field.Args[0].VarName)
+				}
+			}
 			if !strings.HasSuffix(typRef.Definition.Name, "Connection") {
 				return body
 			}
@@ -93,6 +97,24 @@ func (i *gqlResolverImplementer) Implement(body string, field *codegen.Field) st
 	return fmt.Sprintf("return r.Resolver.%s.%s(ctx, obj%s)", field.Object.Name, lo.PascalCase(field.Name), strings.Join(args, ""))
 }
 
+// joinMutation finds the node resolver method of the add or remove mutation of a many to many field.
+func (i *gqlResolverImplementer) joinMutation(name string) (*Node, string) {
+	for _, node := range i.data.Nodes {
+		for _, m := range node.ManyToMany() {
+			if !m.Mutations() {
+				continue
+			}
+			switch name {
+			case m.AddMutation():
+				return node, "Add" + m.Item()
+			case m.RemoveMutation():
+				return node, "Remove" + m.Item()
+			}
+		}
+	}
+	return nil, ""
+}
+
 func (i *gqlResolverImplementer) GenerateCode(cfg *codegen.Data) error {
 	path := filepath.Join(cfg.Config.Resolver.DirName, "schema.genx.gqlresolver.go")
 	text, err := os.ReadFile(path)
@@ -0,0 +1,136 @@
+package relayext
+
+import (
+	"bytes"
+	"maps"
+	"path"
+	"strconv"
+	"text/template"
+
+	"github.com/molon/genx"
+	"github.com/pkg/errors"
+)
+
+// Layout places the generated files, the paths are slash separated and relative to the output dir.
+// The fields left empty take the values of DefaultLayout.
+type Layout struct {
+	// SchemaFile is the enhanced schema
+	SchemaFile string
+	// ModelDir is the directory of the model package
+	ModelDir string
+	// ModelPackage is the name of the model package, defaults to the base of ModelDir
+	ModelPackage string
+	// ModelImportPath is the import path of the model package, defaults to ModelDir in the go module
+	ModelImportPath string
+	ModelFile       string
+	// ResolverDir is the directory of the resolver package
+	ResolverDir string
+	// ResolverPackage is the name of the resolver package, defaults to the base of ResolverDir
+	ResolverPackage  string
+	RootResolverFile string
+	// NodeResolverFile is a template of the name of the resolver file of a node, executed with the *Node
+	NodeResolverFile string
+
+	nodeResolverFile *template.Template
+}
+
+func DefaultLayout() *Layout {
+	return &Layout{
+		SchemaFile:       "schema/schema.genx.graphql",
+		ModelDir:         "server/model",
+		ModelFile:        "models.genx.go",
+		ResolverDir:      "server/resolver",
+		RootResolverFile: "resolver.genx.go",
+		NodeResolverFile: "{{ .Name | snakeCase }}_resolver.genx.go",
+	}
+}
+
+// WithLayout sets the output layout, its empty fields keep their defaults.
+func WithLayout(layout *Layout) Option {
+	return func(e *Extension) {
+		e.layout = layout
+	}
+}
+
+// resolve returns a copy of l completed with the defaults for the go module.
+func (l *Layout) resolve(goModule string, funcs template.FuncMap) (*Layout, error) {
+	resolved := DefaultLayout()
+	if l != nil {
+		for _, v := range []struct {
+			dst *string
+			src string
+		}{
+			{&resolved.SchemaFile, l.SchemaFile},
+			{&resolved.ModelDir, l.ModelDir},
+			{&resolved.ModelPackage, l.ModelPackage},
+			{&resolved.ModelImportPath, l.ModelImportPath},
+			{&resolved.ModelFile, l.ModelFile},
+			{&resolved.ResolverDir, l.ResolverDir},
+			{&resolved.ResolverPackage, l.ResolverPackage},
+			{&resolved.RootResolverFile, l.RootResolverFile},
+			{&resolved.NodeResolverFile, l.NodeResolverFile},
+		} {
+			if v.src != "" {
+				*v.dst = v.src
+			}
+		}
+	}
+	resolved.SchemaFile = path.Clean(resolved.SchemaFile)
+	resolved.ModelDir = path.Clean(resolved.ModelDir)
+	resolved.ResolverDir = path.Clean(resolved.ResolverDir)
+	if resolved.ModelPackage == "" {
+		resolved.ModelPackage = path.Base(resolved.ModelDir)
+	}
+	if resolved.ModelImportPath == "" {
+		resolved.ModelImportPath = path.Join(goModule, resolved.ModelDir)
+	}
+	if resolved.ResolverPackage == "" {
+		resolved.ResolverPackage = path.Base(resolved.ResolverDir)
+	}
+
+	for _, name := range []string{resolved.SchemaFile, resolved.ModelFile, resolved.RootResolverFile} {
+		if !genx.IsGeneratedFile(name) {
+			return nil, errors.Errorf("layout file %s must be named like *.genx.*", name)
+		}
+	}
+	tmpl, err := template.New("nodeResolverFile").Funcs(funcs).Parse(resolved.NodeResolverFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse layout nodeResolverFile")
+	}
+	resolved.nodeResolverFile = tmpl
+	return resolved, nil
+}
+
+// ModelImport is the import spec of the model package in the resolvers, aliased to model if its name differs.
+func (l *Layout) ModelImport() string {
+	if l.ModelPackage == "model" {
+		return strconv.Quote(l.ModelImportPath)
+	}
+	return "model " + strconv.Quote(l.ModelImportPath)
+}
+
+func (l *Layout) modelFile() string {
+	return path.Join(l.ModelDir, l.ModelFile)
+}
+
+func (l *Layout) rootResolverFile() string {
+	return path.Join(l.ResolverDir, l.RootResolverFile)
+}
+
+func (l *Layout) nodeResolverFileOf(node *Node) (string, error) {
+	var buf bytes.Buffer
+	if err := l.nodeResolverFile.Execute(&buf, node); err != nil {
+		return "", errors.Wrapf(err, "failed to execute layout nodeResolverFile for %s", node.Name)
+	}
+	name := buf.String()
+	if !genx.IsGeneratedFile(name) {
+		return "", errors.Errorf("layout file %s of node %s must be named like *.genx.*", name, node.Name)
+	}
+	return path.Join(l.ResolverDir, name), nil
+}
+
+func (e *Extension) templateFuncs() template.FuncMap {
+	funcs := maps.Clone(Funcs)
+	maps.Copy(funcs, e.funcs)
+	return funcs
+}
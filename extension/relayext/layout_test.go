@@ -0,0 +1,59 @@
+package relayext
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateWithLayout(t *testing.T, layout *Layout) (*genx.MemFS, error) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte("type UserProfile @node {\n  name: String!\n}\n"), 0o644))
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(New(WithLayout(layout))))
+	return fsys, err
+}
+
+func TestLayout(t *testing.T) {
+	fsys, err := generateWithLayout(t, &Layout{
+		SchemaFile:       "internal/graph/schema.genx.graphql",
+		ModelDir:         "internal/graph/entity",
+		ModelPackage:     "entities",
+		ResolverDir:      "internal/graph",
+		NodeResolverFile: "{{ .Name | kebabCase }}.resolvers.genx.go",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"internal/graph/entity/models.genx.go",
+		"internal/graph/resolver.genx.go",
+		"internal/graph/schema.genx.graphql",
+		"internal/graph/user-profile.resolvers.genx.go",
+		"prototype.graphql",
+	}, fsys.Names())
+
+	models, err := fs.ReadFile(fsys, "internal/graph/entity/models.genx.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(models), "\npackage entities\n")
+
+	for _, name := range []string{"internal/graph/resolver.genx.go", "internal/graph/user-profile.resolvers.genx.go"} {
+		resolver, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err)
+		assert.Contains(t, string(resolver), "\npackage graph\n", name)
+		assert.Contains(t, string(resolver), `model "github.com/example/app/internal/graph/entity"`, name)
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	_, err := generateWithLayout(t, &Layout{ModelFile: "models.go"})
+	assert.ErrorContains(t, err, "layout file models.go must be named like *.genx.*")
+
+	_, err = generateWithLayout(t, &Layout{NodeResolverFile: "{{ .Name }}.go"})
+	assert.ErrorContains(t, err, "layout file UserProfile.go of node UserProfile must be named like *.genx.*")
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/molon/genx"
 	"github.com/molon/genx/pkg/jsonx"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -15,7 +16,7 @@ import (
 func TestGenerateModels(t *testing.T) {
 	e := New()
 
-	input, err := os.ReadFile("./testdata/schema.genx.graphql")
+	input, err := os.ReadFile("../../starter/boilerplate/schema/schema.genx.graphql")
 	require.NoError(t, err)
 
 	s, err := gqlparser.LoadSchema(&ast.Source{
@@ -29,9 +30,32 @@ func TestGenerateModels(t *testing.T) {
 		Schema: s,
 	}
 
-	generatedFiles, err := e.generateModels(context.Background(), NewData(r, nil))
+	e.templates, err = e.loadTemplates(r)
+	require.NoError(t, err)
+
+	data, err := NewData(r, nil)
+	require.NoError(t, err)
+
+	generatedFiles, err := e.generateModels(context.Background(), data)
 	require.NoError(t, err)
 
 	// TODO：校验生成的结果是否符合预期
 	t.Logf("generatedFiles: %s", jsonx.MustMarshalToString(generatedFiles))
 }
+
+func TestGenerateUnsupportedType(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte(`scalar JSON
+
+type User @node {
+  name: String!
+  settings: JSON
+}
+`), 0o644))
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(New()))
+	assert.ErrorContains(t, err, "unsupported type JSON")
+}
@@ -10,9 +10,12 @@ import (
 
 	_ "embed"
 
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/jinzhu/inflection"
 	"github.com/molon/genx"
 	"github.com/samber/lo"
 	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 type Field interface {
@@ -37,7 +40,16 @@ func (f *ASTField) GoName() string {
 	return name
 }
 
+// GoType is the type of the field in the model, the unsupported types are reported by NewData.
 func (f *ASTField) GoType() types.Type {
+	goType, err := f.goType()
+	if err != nil {
+		return types.Typ[types.Invalid]
+	}
+	return goType
+}
+
+func (f *ASTField) goType() (types.Type, error) {
 	var goType types.Type
 
 	if f.isNodeType() {
@@ -64,7 +76,7 @@ func (f *ASTField) GoType() types.Type {
 				}
 			}
 			if goType == nil {
-				panic(fmt.Sprintf("unsupported type %s", f.Type.Name()))
+				return nil, gqlerror.ErrorPosf(f.Position, "unsupported type %s", f.Type.Name())
 			}
 		}
 	}
@@ -73,7 +85,7 @@ func (f *ASTField) GoType() types.Type {
 		goType = types.NewPointer(goType)
 	}
 
-	return goType
+	return goType, nil
 }
 
 func (f *ASTField) GoTag() string {
@@ -166,6 +178,12 @@ type Node struct {
 	*ast.Definition
 	Schema     *ast.Schema
 	isNodeType func(typ *ast.Definition) bool
+	// inverses are the inverse fields of the one to many fields resolved while enhancing the schema
+	inverses map[string]string
+	// joins are the joins of the many to many fields
+	joins map[string]*Join
+	// oneToMany is resolved by NewData
+	oneToMany []*OneToMany
 }
 
 func (n *Node) ViewerPermission() *ViewerPermission {
@@ -217,24 +235,240 @@ func (n *Node) OneToOne() []*ast.FieldDefinition {
 	})
 }
 
-func (n *Node) OneToMany() []*ast.FieldDefinition {
-	return lo.FilterMap(n.Definition.Fields, func(f *ast.FieldDefinition, _ int) (*ast.FieldDefinition, bool) {
+// OneToMany is a connection field of a node listing the items of another node which reference it.
+type OneToMany struct {
+	*ast.FieldDefinition
+	// Target is the related node
+	Target string
+	// Inverse is the field of the related node referencing the node which scopes the connection,
+	// e.g. User.company for Company.employees
+	Inverse *ASTField
+}
+
+func (n *Node) OneToMany() []*OneToMany {
+	return n.oneToMany
+}
+
+// resolve precomputes the relations of the node and checks the types of its fields,
+// so that the templates only read the data and cannot run into problems of the schema.
+func (n *Node) resolve() error {
+	var fields []*ASTField
+	for _, f := range n.Definition.Fields {
+		if _, ok := n.joins[f.Name]; ok {
+			continue
+		}
 		typName := f.Type.Name()
 		if !strings.HasSuffix(typName, "Connection") {
+			continue
+		}
+		target, ok := n.Schema.Types[strings.TrimSuffix(typName, "Connection")]
+		if !ok || !n.isNodeType(target) {
+			continue
+		}
+		inverse, err := inverseField(n.Name, f, target, n.inverses[f.Name])
+		if err != nil {
+			return err
+		}
+		o := &OneToMany{
+			FieldDefinition: f,
+			Target:          target.Name,
+			Inverse:         &ASTField{inverse, &Node{Definition: target, Schema: n.Schema, isNodeType: n.isNodeType}},
+		}
+		n.oneToMany = append(n.oneToMany, o)
+		fields = append(fields, o.Inverse)
+	}
+
+	for _, f := range n.Fields() {
+		if f, ok := f.(*ASTField); ok {
+			fields = append(fields, f)
+		}
+	}
+	for _, m := range n.ManyToMany() {
+		for _, f := range m.Payload() {
+			fields = append(fields, f.ASTField)
+		}
+	}
+	for _, f := range fields {
+		if _, err := f.goType(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scalarFilters are the filters of the prelude, translated by the generated filterOps.
+var scalarFilters = []string{"BooleanFilter", "EnumFilter", "FloatFilter", "IDFilter", "IntFilter", "StringFilter", "TimeFilter"}
+
+// ScalarFilter is a filter of the prelude.
+type ScalarFilter struct {
+	*ast.Definition
+}
+
+// Ops is the name of the generated func converting the filter into filterOps.
+func (f *ScalarFilter) Ops() string {
+	return lo.CamelCase(f.Name) + "Ops"
+}
+
+// GoType is the type of the operands in the model generated by gqlgen.
+func (f *ScalarFilter) GoType() string {
+	switch f.Fields.ForName("equals").Type.Name() {
+	case "Int":
+		return "int"
+	case "Float":
+		return "float64"
+	case "Boolean":
+		return "bool"
+	case "Time":
+		return "time.Time"
+	default:
+		return "string"
+	}
+}
+
+// PointerLists reports whether the list operands are pointers in the model generated by gqlgen,
+// which is the case for the struct operands, i.e. time.Time.
+func (f *ScalarFilter) PointerLists() bool {
+	return f.GoType() == "time.Time"
+}
+
+// GoNames are the names of the operators in the model generated by gqlgen.
+func (f *ScalarFilter) GoNames() []string {
+	return lo.Map(f.Fields, func(f *ast.FieldDefinition, _ int) string {
+		return templates.ToGo(f.Name)
+	})
+}
+
+// FilterField is a field of the filter of a node, either a scalar filter or the filter of a related node.
+type FilterField struct {
+	*ast.FieldDefinition
+	// GoName is the name of the field in the filter model
+	GoName string
+	// Column is the model field the filter applies to, the foreign key for relations
+	Column string
+	// Scalar is set for scalar fields
+	Scalar *ScalarFilter
+	// Relation is the related node, filtered with a subquery
+	Relation string
+}
+
+// FilterFields are the fields of the filter of the node besides not, and, or.
+// The fields of types other than the prelude filters or node filters are skipped.
+func (n *Node) FilterFields() []*FilterField {
+	def := n.Schema.Types[n.Name+"Filter"]
+	if def == nil || def.Kind != ast.InputObject {
+		return nil
+	}
+	return lo.FilterMap(def.Fields, func(f *ast.FieldDefinition, _ int) (*FilterField, bool) {
+		field := n.Definition.Fields.ForName(f.Name)
+		if field == nil || IsListType(f.Type) {
 			return nil, false
 		}
-		targetTypeName := strings.TrimSuffix(typName, "Connection")
-		targetType, ok := n.Schema.Types[targetTypeName]
-		if !ok {
+		astField := &ASTField{field, n}
+		filterField := &FilterField{
+			FieldDefinition: f,
+			GoName:          templates.ToGo(f.Name),
+			Column:          astField.GoName(),
+		}
+		typeName := f.Type.Name()
+		switch {
+		case slices.Contains(scalarFilters, typeName):
+			filterField.Scalar = &ScalarFilter{n.Schema.Types[typeName]}
+		case astField.isNodeType() && typeName == field.Type.Name()+"Filter":
+			filterField.Relation = field.Type.Name()
+		default:
 			return nil, false
 		}
-		if !n.isNodeType(targetType) {
+		return filterField, true
+	})
+}
+
+// ManyToMany is a field of a node related to the items of another node through a join model.
+type ManyToMany struct {
+	*ast.FieldDefinition
+	Node *Node
+	// Target is the related node
+	Target string
+	Join   *Join
+}
+
+func (n *Node) ManyToMany() []*ManyToMany {
+	return lo.FilterMap(n.Definition.Fields, func(f *ast.FieldDefinition, _ int) (*ManyToMany, bool) {
+		join := n.joins[f.Name]
+		if join == nil {
 			return nil, false
 		}
-		return f, true
+		return &ManyToMany{FieldDefinition: f, Node: n, Target: join.other(n.Name), Join: join}, true
+	})
+}
+
+// Connection is the name of the connection type, specific to the field when the join has a payload.
+func (m *ManyToMany) Connection() string {
+	return joinConnection(m.Node.Name, m.Name, m.Join)
+}
+
+func (m *ManyToMany) Edge() string {
+	return strings.TrimSuffix(m.Connection(), "Connection") + "Edge"
+}
+
+// Item is the singular of the field, e.g. Tag for Post.tags.
+func (m *ManyToMany) Item() string {
+	return lo.PascalCase(inflection.Singular(m.Name))
+}
+
+// OwnerKey and TargetKey are the fields of the join model referencing the node and the related node.
+func (m *ManyToMany) OwnerKey() string {
+	return m.Node.Name + "ID"
+}
+
+func (m *ManyToMany) TargetKey() string {
+	return m.Target + "ID"
+}
+
+// Mutations reports whether the node owns the add and remove mutations of the relation,
+// the first node of the join does.
+func (m *ManyToMany) Mutations() bool {
+	return m.Join.Nodes[0] == m.Node.Name
+}
+
+func (m *ManyToMany) AddMutation() string {
+	add, _ := joinMutations(m.Node.Name, m.Name)
+	return add
+}
+
+func (m *ManyToMany) RemoveMutation() string {
+	_, remove := joinMutations(m.Node.Name, m.Name)
+	return remove
+}
+
+// JoinField is a payload field of a join.
+type JoinField struct {
+	*ASTField
+	// InputName is the name of the field in the input of the add mutation generated by gqlgen
+	InputName string
+}
+
+// Payload are the payload fields of the join.
+func (m *ManyToMany) Payload() []*JoinField {
+	return lo.Map(m.Join.Payload, func(f *ast.FieldDefinition, _ int) *JoinField {
+		return &JoinField{&ASTField{f, m.Node}, templates.ToGo(f.Name)}
 	})
 }
 
+// JoinFields are the fields of the join model, the keys of both nodes followed by the payload.
+func (m *ManyToMany) JoinFields() []Field {
+	fields := lo.Map(m.Join.Nodes, func(node string, _ int) Field {
+		return &GoField{
+			Name: node + "ID",
+			Type: types.Typ[types.String],
+			Tag:  fmt.Sprintf(`gorm:"primaryKey" json:"%s"`, lo.CamelCase(node)+"Id"),
+		}
+	})
+	for _, f := range m.Payload() {
+		fields = append(fields, f)
+	}
+	return fields
+}
+
 func (n *Node) Field(goName string) Field {
 	f, _ := lo.Find(n.Fields(), func(f Field) bool {
 		return f.GoName() == goName
@@ -305,8 +539,39 @@ func (n *Node) Fields() []Field {
 }
 
 type Data struct {
-	Nodes    []*Node
-	GoModule string
+	Nodes          []*Node
+	GoModule       string
+	Layout         *Layout
+	GlobalIDFormat GlobalIDFormat
+
+	schema *ast.Schema
+}
+
+// Joins are the many to many relations from the first node of their joins, one per join model.
+func (d *Data) Joins() []*ManyToMany {
+	var joins []*ManyToMany
+	for _, node := range d.Nodes {
+		for _, m := range node.ManyToMany() {
+			if m.Mutations() {
+				joins = append(joins, m)
+			}
+		}
+	}
+	sort.Slice(joins, func(i, j int) bool {
+		return joins[i].Join.Name < joins[j].Join.Name
+	})
+	return joins
+}
+
+// ScalarFilters are the prelude filters in the schema.
+func (d *Data) ScalarFilters() []*ScalarFilter {
+	return lo.FilterMap(scalarFilters, func(name string, _ int) (*ScalarFilter, bool) {
+		def := d.schema.Types[name]
+		if def == nil || def.Kind != ast.InputObject {
+			return nil, false
+		}
+		return &ScalarFilter{def}, true
+	})
 }
 
 func (d *Data) GetNode(name string) *Node {
@@ -316,7 +581,13 @@ func (d *Data) GetNode(name string) *Node {
 	return node
 }
 
-func NewData(r *genx.Runtime, isNodeType func(def *ast.Definition) bool) *Data {
+// NewData collects the nodes of the schema, the problems of the schema the templates would run into are returned.
+func NewData(r *genx.Runtime, isNodeType func(def *ast.Definition) bool) (*Data, error) {
+	return newData(r, isNodeType, nil, nil)
+}
+
+// newData is NewData with the inverses and joins resolved while enhancing the schema, by node and field name.
+func newData(r *genx.Runtime, isNodeType func(def *ast.Definition) bool, inverses map[string]map[string]string, joins map[string]map[string]*Join) (*Data, error) {
 	if isNodeType == nil {
 		isNodeType = func(def *ast.Definition) bool {
 			return def.Kind == ast.Object && def.Directives.ForName(directiveNode) != nil
@@ -326,7 +597,7 @@ func NewData(r *genx.Runtime, isNodeType func(def *ast.Definition) bool) *Data {
 		return isNodeType(def)
 	})
 	nodes := lo.MapToSlice(nodeTypes, func(key string, def *ast.Definition) *Node {
-		return &Node{Definition: def, Schema: r.Schema, isNodeType: isNodeType}
+		return &Node{Definition: def, Schema: r.Schema, isNodeType: isNodeType, inverses: inverses[key], joins: joins[key]}
 	})
 	sort.Slice(nodes, func(i, j int) bool {
 		return nodes[i].Name < nodes[j].Name
@@ -338,8 +609,19 @@ func NewData(r *genx.Runtime, isNodeType func(def *ast.Definition) bool) *Data {
 		// }
 		// return nodes[i].Position.Line < nodes[j].Position.Line
 	})
+	for _, node := range nodes {
+		if err := node.resolve(); err != nil {
+			return nil, err
+		}
+	}
+	layout, err := DefaultLayout().resolve(r.GoModule, Funcs)
+	if err != nil {
+		return nil, err
+	}
 	return &Data{
 		Nodes:    nodes,
 		GoModule: r.GoModule,
-	}
+		Layout:   layout,
+		schema:   r.Schema,
+	}, nil
 }
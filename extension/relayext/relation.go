@@ -0,0 +1,349 @@
+package relayext
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/huandu/go-clone"
+	"github.com/jinzhu/inflection"
+	"github.com/samber/lo"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Join is the join model of a many to many relation between two nodes, named after them in alphabetical order
+// unless set with @relation(through:). A non-node object type of the same name declares the payload of the join.
+type Join struct {
+	Name string
+	// Nodes are the related nodes in alphabetical order
+	Nodes []string
+	// Payload are the fields of the declared join type, stored in the join model and exposed on the edges
+	Payload []*ast.FieldDefinition
+
+	// fields are the many to many fields of the related nodes
+	fields map[string]string
+}
+
+func (j *Join) other(node string) string {
+	if j.Nodes[0] == node {
+		return j.Nodes[1]
+	}
+	return j.Nodes[0]
+}
+
+// relations are the list fields of the nodes resolved by resolveRelations.
+type relations struct {
+	// inverses maps the one to many fields of the nodes to the fields of the related nodes referencing them
+	inverses map[string]map[string]string
+	// joins maps the many to many fields of the nodes to their joins
+	joins map[string]map[string]*Join
+}
+
+func relationArgs(field *ast.FieldDefinition) (inverse, through string) {
+	relation := field.Directives.ForName(directiveRelation)
+	if relation == nil {
+		return "", ""
+	}
+	if arg := relation.Arguments.ForName("inverse"); arg != nil && arg.Value != nil {
+		inverse = arg.Value.Raw
+	}
+	if arg := relation.Arguments.ForName("through"); arg != nil && arg.Value != nil {
+		through = arg.Value.Raw
+	}
+	return inverse, through
+}
+
+// resolveRelations resolves the list fields of nodes into one to many relations, scoped by the inverse field of
+// the related node, or many to many relations when the inverse field is a list as well.
+func resolveRelations(sd *ast.SchemaDocument) (*relations, error) {
+	r := &relations{
+		inverses: make(map[string]map[string]string),
+		joins:    make(map[string]map[string]*Join),
+	}
+	joins := make(map[string]*Join)
+	var errs gqlerror.List
+	for _, typ := range sd.Definitions {
+		if typ.Kind != ast.Object || !directiveExists(typ, directiveNode) {
+			continue
+		}
+		for _, field := range typ.Fields {
+			if _, exists := reservedFields[field.Name]; exists {
+				continue
+			}
+			// skip non-list type
+			if field.Type.Elem == nil {
+				continue
+			}
+			// skip if the type is not an object with node directive
+			def := findDefinition(sd, field.Type.Elem.NamedType)
+			if def == nil || def.Kind != ast.Object || !directiveExists(def, directiveNode) {
+				continue
+			}
+			if !field.Type.NonNull {
+				errs = append(errs, gqlerror.ErrorPosf(field.Position, "field %s.%s should be non-null", typ.Name, field.Name))
+				continue
+			}
+			if !field.Type.Elem.NonNull {
+				errs = append(errs, gqlerror.ErrorPosf(field.Position, "elem of field %s.%s should be non-null", typ.Name, field.Name))
+				continue
+			}
+
+			name, through := relationArgs(field)
+			inverse, err := inverseField(typ.Name, field, def, name)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if !IsListType(inverse.Type) {
+				if through != "" {
+					errs = append(errs, gqlerror.ErrorPosf(field.Position, "field %s.%s is one to many, through only applies to many to many relations", typ.Name, field.Name))
+					continue
+				}
+				if r.inverses[typ.Name] == nil {
+					r.inverses[typ.Name] = make(map[string]string)
+				}
+				r.inverses[typ.Name][field.Name] = inverse.Name
+				continue
+			}
+
+			join, err := resolveJoin(sd, joins, typ, field, def, inverse, through)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if r.joins[typ.Name] == nil {
+				r.joins[typ.Name] = make(map[string]*Join)
+			}
+			r.joins[typ.Name][field.Name] = join
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return r, nil
+}
+
+func resolveJoin(sd *ast.SchemaDocument, joins map[string]*Join, typ *ast.Definition, field *ast.FieldDefinition, target *ast.Definition, inverse *ast.FieldDefinition, through string) (*Join, *gqlerror.Error) {
+	if target.Name == typ.Name {
+		return nil, gqlerror.ErrorPosf(field.Position, "many to many field %s.%s of its own type is not supported", typ.Name, field.Name)
+	}
+	if _, inverseThrough := relationArgs(inverse); inverseThrough != "" {
+		if through != "" && through != inverseThrough {
+			return nil, gqlerror.ErrorPosf(field.Position, "field %s.%s is joined through %s but its inverse %s.%s through %s",
+				typ.Name, field.Name, through, target.Name, inverse.Name, inverseThrough)
+		}
+		through = inverseThrough
+	}
+	nodes := []string{typ.Name, target.Name}
+	slices.Sort(nodes)
+	if through == "" {
+		through = nodes[0] + nodes[1]
+	}
+
+	join := joins[through]
+	if join == nil {
+		join = &Join{Name: through, Nodes: nodes, fields: make(map[string]string)}
+		if def := findDefinition(sd, through); def != nil {
+			if def.Kind != ast.Object || directiveExists(def, directiveNode) {
+				return nil, gqlerror.ErrorPosf(def.Position, "join type %s of field %s.%s should be an object type without @node", through, typ.Name, field.Name)
+			}
+			for _, f := range def.Fields {
+				if err := validateJoinField(sd, def, f); err != nil {
+					return nil, err
+				}
+			}
+			join.Payload = def.Fields
+		}
+		joins[through] = join
+	}
+	if !slices.Equal(join.Nodes, nodes) {
+		return nil, gqlerror.ErrorPosf(field.Position, "join %s of field %s.%s already joins %s and %s", through, typ.Name, field.Name, join.Nodes[0], join.Nodes[1])
+	}
+	if other, ok := join.fields[typ.Name]; ok {
+		return nil, gqlerror.ErrorPosf(field.Position, "fields %s.%s and %s.%s are both joined through %s, name their joins with @relation(through:)",
+			typ.Name, other, typ.Name, field.Name, through)
+	}
+	join.fields[typ.Name] = field.Name
+	return join, nil
+}
+
+func validateJoinField(sd *ast.SchemaDocument, def *ast.Definition, f *ast.FieldDefinition) *gqlerror.Error {
+	if !IsListType(f.Type) && !IsMethodField(f) {
+		if _, ok := supportedScalars[f.Type.Name()]; ok {
+			return nil
+		}
+		if typ := findDefinition(sd, f.Type.Name()); typ != nil && typ.Kind == ast.Enum {
+			return nil
+		}
+	}
+	return gqlerror.ErrorPosf(f.Position, "unsupported type %s of join field %s.%s", f.Type.String(), def.Name, f.Name)
+}
+
+// inverseField returns the field of target referencing owner which scopes the list field of owner,
+// named by @relation(inverse:) or else the only candidate. Single fields are preferred over lists.
+func inverseField(owner string, field *ast.FieldDefinition, target *ast.Definition, name string) (*ast.FieldDefinition, *gqlerror.Error) {
+	candidates := lo.Filter(target.Fields, func(f *ast.FieldDefinition, _ int) bool {
+		return f != field && !IsMethodField(f) && f.Type.Name() == owner
+	})
+	if name != "" {
+		inverse, ok := lo.Find(candidates, func(f *ast.FieldDefinition) bool {
+			return f.Name == name
+		})
+		if !ok {
+			return nil, gqlerror.ErrorPosf(field.Position, "inverse %s of field %s.%s is not a field of type %s in %s", name, owner, field.Name, owner, target.Name)
+		}
+		return inverse, nil
+	}
+	if singles := lo.Reject(candidates, func(f *ast.FieldDefinition, _ int) bool { return IsListType(f.Type) }); len(singles) > 0 {
+		candidates = singles
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, gqlerror.ErrorPosf(field.Position, "field %s.%s has no inverse field of type %s in %s", owner, field.Name, owner, target.Name)
+	case 1:
+		return candidates[0], nil
+	}
+	names := lo.Map(candidates, func(f *ast.FieldDefinition, _ int) string { return f.Name })
+	return nil, gqlerror.ErrorPosf(field.Position, "field %s.%s has several inverse fields in %s (%s), choose one with @relation(inverse:)",
+		owner, field.Name, target.Name, strings.Join(names, ", "))
+}
+
+// joinConnection is the name of the connection type of a many to many field, specific to the field
+// when the join has a payload to expose on its edges.
+func joinConnection(owner, field string, join *Join) string {
+	if len(join.Payload) > 0 {
+		return owner + lo.PascalCase(field) + "Connection"
+	}
+	return join.other(owner) + "Connection"
+}
+
+// joinMutations are the names of the mutations adding and removing the items of a many to many field,
+// e.g. addTagToPost and removeTagFromPost for Post.tags.
+func joinMutations(owner, field string) (add, remove string) {
+	item := lo.PascalCase(inflection.Singular(field))
+	return "add" + item + "To" + owner, "remove" + item + "From" + owner
+}
+
+func ensureJoinConnectionTypes(sd *ast.SchemaDocument, typ *ast.Definition, joins map[string]*Join) (defs []*ast.Definition) {
+	for _, field := range typ.Fields {
+		join := joins[field.Name]
+		if join == nil || len(join.Payload) == 0 {
+			continue
+		}
+		target := join.other(typ.Name)
+		connectionName := joinConnection(typ.Name, field.Name, join)
+		edgeName := strings.TrimSuffix(connectionName, "Connection") + "Edge"
+		if !definitionExists(sd, connectionName) {
+			defs = append(defs, &ast.Definition{
+				Kind: ast.Object,
+				Name: connectionName,
+				Fields: []*ast.FieldDefinition{
+					{Name: "nodes", Type: ast.NonNullListType(ast.NonNullNamedType(target, nil), nil)},
+					{Name: "edges", Type: ast.NonNullListType(ast.NonNullNamedType(edgeName, nil), nil)},
+					{Name: "pageInfo", Type: ast.NonNullNamedType("PageInfo", nil)},
+					{Name: "totalCount", Type: ast.NamedType("Int", nil)},
+				},
+			})
+		}
+		if !definitionExists(sd, edgeName) {
+			fields := []*ast.FieldDefinition{
+				{Name: "node", Type: ast.NonNullNamedType(target, nil)},
+				{Name: "cursor", Type: ast.NonNullNamedType("Cursor", nil)},
+			}
+			for _, f := range join.Payload {
+				fields = append(fields, &ast.FieldDefinition{Name: f.Name, Type: clone.Slowly(f.Type).(*ast.Type)})
+			}
+			defs = append(defs, &ast.Definition{Kind: ast.Object, Name: edgeName, Fields: fields})
+		}
+	}
+	return defs
+}
+
+// ensureJoinMutations adds the mutations of the many to many fields of the first node of their joins.
+func ensureJoinMutations(sd *ast.SchemaDocument, typ *ast.Definition, joins map[string]*Join) (exts []*ast.Definition) {
+	methods := parseMethods(sd, "Mutation")
+
+	var extMethods []*ast.FieldDefinition
+	for _, field := range typ.Fields {
+		join := joins[field.Name]
+		if join == nil || join.Nodes[0] != typ.Name {
+			continue
+		}
+		add, remove := joinMutations(typ.Name, field.Name)
+		for _, methodName := range []string{add, remove} {
+			if !methodExists(methods, methodName) {
+				extMethods = append(extMethods, &ast.FieldDefinition{
+					Name: methodName,
+					Type: ast.NonNullNamedType(lo.PascalCase(methodName+"Payload"), nil),
+					Arguments: ast.ArgumentDefinitionList{
+						{Name: "input", Type: ast.NonNullNamedType(lo.PascalCase(methodName+"Input"), nil)},
+					},
+				})
+			}
+		}
+	}
+
+	if len(extMethods) > 0 {
+		exts = append(exts, &ast.Definition{
+			Kind:   ast.Object,
+			Name:   "Mutation",
+			Fields: extMethods,
+		})
+	}
+	return exts
+}
+
+func ensureJoinMutationTypes(sd *ast.SchemaDocument, typ *ast.Definition, joins map[string]*Join) (defs []*ast.Definition) {
+	for _, field := range typ.Fields {
+		join := joins[field.Name]
+		if join == nil || join.Nodes[0] != typ.Name {
+			continue
+		}
+		target := join.other(typ.Name)
+		item := inflection.Singular(field.Name)
+		add, remove := joinMutations(typ.Name, field.Name)
+		for _, methodName := range []string{add, remove} {
+			inputName := lo.PascalCase(methodName + "Input")
+			if !definitionExists(sd, inputName) {
+				fields := []*ast.FieldDefinition{
+					{Name: "clientMutationId", Type: ast.NamedType("String", nil)},
+					{Name: lo.CamelCase(typ.Name + "Id"), Type: ast.NonNullNamedType("ID", nil)},
+					{Name: item + "Id", Type: ast.NonNullNamedType("ID", nil)},
+				}
+				if methodName == add {
+					for _, f := range join.Payload {
+						fields = append(fields, &ast.FieldDefinition{Name: f.Name, Type: clone.Slowly(f.Type).(*ast.Type)})
+					}
+				}
+				defs = append(defs, &ast.Definition{Kind: ast.InputObject, Name: inputName, Fields: fields})
+			}
+
+			payloadName := lo.PascalCase(methodName + "Payload")
+			if !definitionExists(sd, payloadName) {
+				defs = append(defs, &ast.Definition{
+					Kind: ast.Object,
+					Name: payloadName,
+					Fields: []*ast.FieldDefinition{
+						{Name: "clientMutationId", Type: ast.NamedType("String", nil)},
+						{Name: lo.CamelCase(typ.Name), Type: ast.NonNullNamedType(typ.Name, nil)},
+						{Name: item, Type: ast.NonNullNamedType(target, nil)},
+					},
+				})
+			}
+		}
+	}
+	return defs
+}
+
+// joinNames are the names of the joins of the many to many relations.
+func (r *relations) joinNames() []string {
+	var names []string
+	for _, joins := range r.joins {
+		for _, join := range joins {
+			if !slices.Contains(names, join.Name) {
+				names = append(names, join.Name)
+			}
+		}
+	}
+	return names
+}
@@ -3,9 +3,14 @@ package relayext
 import (
 	"context"
 	"os"
+	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/molon/genx"
+	"github.com/molon/genx/extension/gqlgenext"
+	"github.com/molon/genx/genxtest"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -14,7 +19,7 @@ import (
 func TestGenerateResolvers(t *testing.T) {
 	e := New()
 
-	input, err := os.ReadFile("./testdata/schema.genx.graphql")
+	input, err := os.ReadFile("../../starter/boilerplate/schema/schema.genx.graphql")
 	require.NoError(t, err)
 
 	s, err := gqlparser.LoadSchema(&ast.Source{
@@ -28,9 +33,130 @@ func TestGenerateResolvers(t *testing.T) {
 		Schema: s,
 	}
 
-	generatedFiles, err := e.generateResolvers(context.Background(), NewData(r, nil))
+	e.templates, err = e.loadTemplates(r)
+	require.NoError(t, err)
+
+	data, err := NewData(r, nil)
+	require.NoError(t, err)
+
+	generatedFiles, err := e.generateResolvers(context.Background(), data)
 	require.NoError(t, err)
 
 	// TODO：校验生成的结果是否符合预期
 	t.Logf("generatedFiles: %+v", generatedFiles)
 }
+
+// starterModule is the module of the starter, its gqlgen.yml autobinds the models of it.
+const starterModule = "github.com/molon/genx/starter/boilerplate"
+
+// TestGenerateStarter runs the extension with gqlgen in the starter module and compiles the output. The go.mod of
+// testdata/starter requires a sqlite driver besides the requirements of the starter, the test files of
+// testdata/starter named by the cases query a sqlite database through the generated server.
+func TestGenerateStarter(t *testing.T) {
+	genxtest.SkipIfToolchainUnsupported(t)
+
+	for _, c := range []struct {
+		name      string
+		prototype string
+		options   []Option
+		tests     []string
+	}{
+		{
+			name: "filter",
+			prototype: `type Company @node {
+  name: String!
+}
+
+type User @node {
+  name: String
+  age: Int!
+  joinedAt: Time!
+  company: Company!
+}
+`,
+			tests: []string{"server/filter_test.go"},
+		},
+		{
+			name: "many_to_many",
+			prototype: `type Post @node {
+  title: String!
+  tags: [Tag!]!
+}
+
+type Tag @node {
+  name: String!
+  posts: [Post!]!
+}
+
+type PostTag {
+  note: String
+}
+`,
+			tests: []string{"server/many_to_many_test.go"},
+		},
+		{
+			name: "partitions",
+			prototype: `type Company @node {
+  employees: [User!]!
+}
+
+type User @node {
+  age: Int!
+  company: Company
+}
+`,
+			tests: []string{"server/partition_test.go"},
+		},
+		{
+			name:      "global_id_base64",
+			prototype: "type UserProfile @node {\n  name: String!\n}\n",
+			options:   []Option{WithGlobalIDFormat(GlobalIDBase64)},
+			tests:     []string{"server/node_test.go"},
+		},
+		{
+			name:      "global_id_prefixed",
+			prototype: "type UserProfile @node {\n  name: String!\n}\n",
+			options:   []Option{WithGlobalIDFormat(GlobalIDPrefixed)},
+			tests:     []string{"server/node_test.go"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			read := func(name string) string {
+				content, err := os.ReadFile(filepath.FromSlash(name))
+				require.NoError(t, err)
+				return string(content)
+			}
+			files := map[string]string{
+				"go.mod":                genxtest.ReadGoMod(t, "testdata/starter/go.mod"),
+				"go.sum":                read("testdata/starter/go.sum"),
+				"gqlgen.yml":            read("../../starter/boilerplate/gqlgen.yml"),
+				"server/gqlresolver.go": read("../../starter/boilerplate/server/gqlresolver.go"),
+			}
+			for _, name := range append(c.tests, "server/db_test.go") {
+				files[name] = read(path.Join("testdata/starter", name))
+			}
+
+			genxtest.Run(t, &genxtest.Case{
+				Prototype:  c.prototype,
+				Files:      files,
+				GoModule:   starterModule,
+				Extensions: []genx.Extension{New(c.options...), gqlgenext.New()},
+				// the outputs of gqlgen itself
+				Ignore:  []string{"server/exec/*.generated.go", "server/model/models.gqlgen.go"},
+				Compile: true,
+				Test:    true,
+			})
+		})
+	}
+}
+
+func TestGenerateUnknownGlobalIDFormat(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte("type UserProfile @node {\n  name: String!\n}\n"), 0o644))
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(New(WithGlobalIDFormat("uuid"))))
+	assert.ErrorContains(t, err, "unknown global id format uuid")
+}
@@ -13,13 +13,17 @@ import (
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 	"github.com/vektah/gqlparser/v2/parser"
 )
 
 //go:embed embed/prelude.relay.genx.graphql
 var prelude string
 
-const directiveNode = "node"
+const (
+	directiveNode     = "node"
+	directiveRelation = "relation"
+)
 
 var reservedFields = map[string]struct{}{
 	"viewerPermission": {},
@@ -28,6 +32,10 @@ var reservedFields = map[string]struct{}{
 type enhanceSchemaResult struct {
 	Document *ast.SchemaDocument
 	Nodes    map[string]*ast.Definition
+	// Inverses maps the one to many fields of the nodes to the fields of the related nodes referencing them
+	Inverses map[string]map[string]string
+	// Joins maps the many to many fields of the nodes to their joins
+	Joins map[string]map[string]*Join
 }
 
 func enhanceSchema(_ context.Context, doc *ast.SchemaDocument) (*enhanceSchemaResult, error) {
@@ -40,13 +48,25 @@ func enhanceSchema(_ context.Context, doc *ast.SchemaDocument) (*enhanceSchemaRe
 	}
 	sd.Merge(doc)
 
+	relations, err := resolveRelations(sd)
+	if err != nil {
+		return nil, err
+	}
+	joinNames := relations.joinNames()
+
 	r := &enhanceSchemaResult{
 		Document: sd,
 		Nodes:    make(map[string]*ast.Definition),
+		Inverses: relations.inverses,
+		Joins:    relations.joins,
 	}
 
 	var defs, exts ast.DefinitionList
 	for _, def := range sd.Definitions {
+		// the declared join types only exist in the models
+		if slices.Contains(joinNames, def.Name) {
+			continue
+		}
 		defs = append(defs, def)
 
 		if def.Kind != ast.Object {
@@ -60,18 +80,28 @@ func enhanceSchema(_ context.Context, doc *ast.SchemaDocument) (*enhanceSchemaRe
 		r.Nodes[def.Name] = def
 
 		ensureBuiltInNodeFields(def)
-		if err := ensureFieldConnections(sd, def); err != nil {
-			return nil, err
-		}
+		ensureNodeInterface(def)
+		ensureFieldConnections(sd, def, relations.joins[def.Name])
 		exts = append(exts, ensureQuery(sd, def)...)
 		defs = append(defs, ensureConnectionTypes(sd, def)...)
+		defs = append(defs, ensureJoinConnectionTypes(sd, def, relations.joins[def.Name])...)
 		defs = append(defs, ensureFilter(sd, def)...)
 		defs = append(defs, ensureOrderTypes(sd, def)...)
 		exts = append(exts, ensureMutation(sd, def)...)
 		defs = append(defs, ensureMutationTypes(sd, def)...)
+		exts = append(exts, ensureJoinMutations(sd, def, relations.joins[def.Name])...)
+		defs = append(defs, ensureJoinMutationTypes(sd, def, relations.joins[def.Name])...)
 		defs = append(defs, ensureViewerPermission(sd, def)...)
 	}
 
+	if len(r.Nodes) > 0 {
+		exts = append(exts, ensureNodeQueries(sd)...)
+	}
+
+	if err := validateNodeFields(sd, r.Nodes); err != nil {
+		return nil, err
+	}
+
 	// TODO: 需要处理完全没有设置 node 标记的情况
 	// TODO: 需要为 node 设置全局配置
 	// TODO: 针对于 prelude 还是需要防止重复定义的问题
@@ -79,15 +109,47 @@ func enhanceSchema(_ context.Context, doc *ast.SchemaDocument) (*enhanceSchemaRe
 	sd.Definitions = defs
 	sd.Extensions = append(sd.Extensions, exts...)
 
-	// TODO: 这个逻辑其实不应该在这里，这是 gqlparser 的问题，其实可以自写一份 formatter，并且其 formatter 对 extends 的顺序处理的也不到位
-	addNewlineAfterDescription(sd)
-
-	// remove dummy directive
-	removeDirectives(sd, directiveNode)
+	// remove dummy directives
+	removeDirectives(sd, directiveNode, directiveRelation)
 
 	return r, nil
 }
 
+var supportedScalars = map[string]struct{}{
+	"Int": {}, "Float": {}, "String": {}, "Boolean": {}, "ID": {}, "Time": {},
+}
+
+// validateNodeFields reports the fields of the nodes whose type cannot be mapped to a model field,
+// the positions refer to the prototype files.
+func validateNodeFields(sd *ast.SchemaDocument, nodes map[string]*ast.Definition) error {
+	var errs gqlerror.List
+	for _, def := range sd.Definitions {
+		if _, ok := nodes[def.Name]; !ok {
+			continue
+		}
+		for _, field := range def.Fields {
+			if _, exists := reservedFields[field.Name]; exists || IsMethodField(field) {
+				continue
+			}
+			typName := field.Type.Name()
+			if _, ok := supportedScalars[typName]; ok {
+				continue
+			}
+			if _, ok := nodes[typName]; ok {
+				continue
+			}
+			if typ := findDefinition(sd, typName); typ != nil && typ.Kind == ast.Enum {
+				continue
+			}
+			errs = append(errs, gqlerror.ErrorPosf(field.Position, "unsupported type %s of field %s.%s", typName, def.Name, field.Name))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 func ensureBuiltInNodeFields(typ *ast.Definition) {
 	existingFields := lo.SliceToMap(typ.Fields, func(f *ast.FieldDefinition) (string, *ast.FieldDefinition) {
 		return f.Name, f
@@ -108,6 +170,47 @@ func ensureBuiltInNodeFields(typ *ast.Definition) {
 	sortNodeFields(typ.Fields)
 }
 
+const nodeInterface = "Node"
+
+func ensureNodeInterface(typ *ast.Definition) {
+	if !slices.Contains(typ.Interfaces, nodeInterface) {
+		typ.Interfaces = append(typ.Interfaces, nodeInterface)
+	}
+}
+
+// ensureNodeQueries adds the queries refetching the nodes by their global ids.
+func ensureNodeQueries(sd *ast.SchemaDocument) (exts []*ast.Definition) {
+	methods := parseMethods(sd, "Query")
+
+	var extMethods []*ast.FieldDefinition
+	if !methodExists(methods, "node") {
+		extMethods = append(extMethods, &ast.FieldDefinition{
+			Name: "node",
+			Type: ast.NamedType(nodeInterface, nil),
+			Arguments: ast.ArgumentDefinitionList{
+				{Name: "id", Type: ast.NonNullNamedType("ID", nil)},
+			},
+		})
+	}
+	if !methodExists(methods, "nodes") {
+		extMethods = append(extMethods, &ast.FieldDefinition{
+			Name: "nodes",
+			Type: ast.NonNullListType(ast.NamedType(nodeInterface, nil), nil),
+			Arguments: ast.ArgumentDefinitionList{
+				{Name: "ids", Type: ast.NonNullListType(ast.NonNullNamedType("ID", nil), nil)},
+			},
+		})
+	}
+	if len(extMethods) > 0 {
+		exts = append(exts, &ast.Definition{
+			Kind:   ast.Object,
+			Name:   "Query",
+			Fields: extMethods,
+		})
+	}
+	return exts
+}
+
 var builtInNodeFieldOrder = map[string]int{"id": 1, "createdAt": 2, "updatedAt": 3}
 
 func sortNodeFields(fields []*ast.FieldDefinition) {
@@ -139,7 +242,8 @@ func connectionMethod(typName, methodName string) *ast.FieldDefinition {
 	}
 }
 
-func ensureFieldConnections(sd *ast.SchemaDocument, typ *ast.Definition) error {
+// ensureFieldConnections turns the list fields of nodes, resolved by resolveRelations, into connections.
+func ensureFieldConnections(sd *ast.SchemaDocument, typ *ast.Definition, joins map[string]*Join) {
 	for idx, field := range typ.Fields {
 		if _, exists := reservedFields[field.Name]; exists {
 			continue
@@ -153,15 +257,12 @@ func ensureFieldConnections(sd *ast.SchemaDocument, typ *ast.Definition) error {
 		if def == nil || def.Kind != ast.Object || !directiveExists(def, directiveNode) {
 			continue
 		}
-		if !field.Type.NonNull {
-			return errors.Errorf("field %s.%s should be non-null", typ.Name, field.Name)
+		method := connectionMethod(field.Type.Elem.NamedType, field.Name)
+		if join := joins[field.Name]; join != nil {
+			method.Type = ast.NonNullNamedType(joinConnection(typ.Name, field.Name, join), nil)
 		}
-		if !field.Type.Elem.NonNull {
-			return errors.Errorf("elem of field %s.%s should be non-null", typ.Name, field.Name)
-		}
-		typ.Fields[idx] = connectionMethod(field.Type.Elem.NamedType, field.Name)
+		typ.Fields[idx] = method
 	}
-	return nil
 }
 
 func ensureQuery(sd *ast.SchemaDocument, typ *ast.Definition) (exts []*ast.Definition) {
@@ -497,15 +598,6 @@ func parseMethods(sd *ast.SchemaDocument, name string) []*ast.FieldDefinition {
 	})
 }
 
-func addNewlineAfterDescription(sd *ast.SchemaDocument) {
-	for _, def := range append(sd.Definitions, sd.Extensions...) {
-		if def.AfterDescriptionComment == nil {
-			def.AfterDescriptionComment = &ast.CommentGroup{}
-		}
-		def.AfterDescriptionComment.List = append(def.AfterDescriptionComment.List, &ast.Comment{Value: "\n"})
-	}
-}
-
 func removeDirectives(sd *ast.SchemaDocument, directiveNames ...string) {
 	sd.Directives = lo.Filter(sd.Directives, func(d *ast.DirectiveDefinition, _ int) bool {
 		return !slices.Contains(directiveNames, d.Name)
@@ -6,7 +6,9 @@ import (
 	"os"
 	"testing"
 
+	"github.com/molon/genx"
 	"github.com/molon/genx/pkg/gqlx"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -51,3 +53,207 @@ type User @node {
 	}
 	// TODO：校验生成的 schema 是否符合预期
 }
+
+func TestEnhanceSchemaInverses(t *testing.T) {
+	enhance := func(input string) (*enhanceSchemaResult, error) {
+		sd, err := parser.ParseSchemas(&ast.Source{Name: "prototype.graphql", Input: input})
+		require.NoError(t, err)
+		return enhanceSchema(context.Background(), sd)
+	}
+
+	r, err := enhance(`
+type Company @node {
+  employees: [User!]! @relation(inverse: "employer")
+  contractors: [User!]! @relation(inverse: "client")
+}
+
+type User @node {
+  employer: Company!
+  client: Company
+  tasks: [Task!]!
+}
+
+type Task @node {
+  assignee: User
+}
+`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"employees": "employer", "contractors": "client"}, r.Inverses["Company"])
+	assert.Equal(t, map[string]string{"tasks": "assignee"}, r.Inverses["User"])
+	assert.NotContains(t, gqlx.FormatDocument(r.Document), "@relation")
+
+	_, err = enhance(`
+type Company @node {
+  employees: [User!]!
+}
+
+type User @node {
+  employer: Company!
+  client: Company
+}
+`)
+	assert.ErrorContains(t, err, "prototype.graphql:3: field Company.employees has several inverse fields in User (employer, client), choose one with @relation(inverse:)")
+
+	_, err = enhance(`
+type Company @node {
+  employees: [User!]!
+}
+
+type User @node {
+  name: String!
+}
+`)
+	assert.ErrorContains(t, err, "prototype.graphql:3: field Company.employees has no inverse field of type Company in User")
+
+	_, err = enhance(`
+type Company @node {
+  employees: [User!]! @relation(inverse: "company")
+}
+
+type User @node {
+  employer: Company!
+}
+`)
+	assert.ErrorContains(t, err, "inverse company of field Company.employees is not a field of type Company in User")
+
+	_, err = enhance(`
+type Company @node {
+  employees: [User!]
+  contractors: [User]!
+}
+
+type User @node {
+  company: Company!
+}
+`)
+	assert.ErrorContains(t, err, "prototype.graphql:3: field Company.employees should be non-null")
+	assert.ErrorContains(t, err, "prototype.graphql:4: elem of field Company.contractors should be non-null")
+}
+
+func TestEnhanceSchemaJoins(t *testing.T) {
+	enhance := func(input string) (*enhanceSchemaResult, error) {
+		sd, err := parser.ParseSchemas(&ast.Source{Name: "prototype.graphql", Input: input})
+		require.NoError(t, err)
+		return enhanceSchema(context.Background(), sd)
+	}
+
+	r, err := enhance(`
+type Post @node {
+  tags: [Tag!]!
+}
+
+type Tag @node {
+  posts: [Post!]!
+}
+
+type PostTag {
+  addedAt: Time!
+}
+`)
+	require.NoError(t, err)
+	join := r.Joins["Post"]["tags"]
+	require.NotNil(t, join)
+	assert.Same(t, join, r.Joins["Tag"]["posts"])
+	assert.Equal(t, "PostTag", join.Name)
+	assert.Equal(t, []string{"Post", "Tag"}, join.Nodes)
+	assert.Len(t, join.Payload, 1)
+	assert.Empty(t, r.Inverses["Post"])
+
+	schema := gqlx.FormatDocument(r.Document)
+	assert.NotContains(t, schema, "type PostTag ")
+	for _, def := range []string{
+		"tags(after: Cursor, first: Int, before: Cursor, last: Int, filterBy: TagFilter, orderBy: [TagOrder!]): PostTagsConnection!",
+		"posts(after: Cursor, first: Int, before: Cursor, last: Int, filterBy: PostFilter, orderBy: [PostOrder!]): TagPostsConnection!",
+		"addTagToPost(input: AddTagToPostInput!): AddTagToPostPayload!",
+		"removeTagFromPost(input: RemoveTagFromPostInput!): RemoveTagFromPostPayload!",
+	} {
+		assert.Contains(t, schema, def)
+	}
+	_, err = gqlparser.LoadSchema(&ast.Source{Name: "schema.genx.graphql", Input: schema})
+	require.NoError(t, err)
+
+	r, err = enhance(`
+type Post @node {
+  tags: [Tag!]! @relation(inverse: "posts", through: "Tagging")
+  labels: [Tag!]! @relation(inverse: "labeled")
+}
+
+type Tag @node {
+  posts: [Post!]! @relation(inverse: "tags")
+  labeled: [Post!]! @relation(inverse: "labels")
+}
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "Tagging", r.Joins["Tag"]["posts"].Name)
+	assert.Equal(t, "PostTag", r.Joins["Tag"]["labeled"].Name)
+
+	_, err = enhance(`
+type Post @node {
+  tags: [Tag!]! @relation(inverse: "posts")
+  labels: [Tag!]! @relation(inverse: "labeled")
+}
+
+type Tag @node {
+  posts: [Post!]! @relation(inverse: "tags")
+  labeled: [Post!]! @relation(inverse: "labels")
+}
+`)
+	assert.ErrorContains(t, err, "fields Post.tags and Post.labels are both joined through PostTag, name their joins with @relation(through:)")
+
+	_, err = enhance(`
+type Post @node {
+  tags: [Tag!]!
+}
+
+type Tag @node {
+  posts: [Post!]!
+}
+
+type PostTag {
+  posts: [Post!]!
+}
+`)
+	assert.ErrorContains(t, err, "unsupported type [Post!]! of join field PostTag.posts")
+}
+
+func TestEnhanceSchemaNodeInterface(t *testing.T) {
+	sd, err := parser.ParseSchemas(&ast.Source{Name: "prototype.graphql", Input: `
+type User @node {
+  name: String!
+}
+
+type Address {
+  city: String!
+}
+`})
+	require.NoError(t, err)
+	r, err := enhanceSchema(context.Background(), sd)
+	require.NoError(t, err)
+
+	schema := gqlx.FormatDocument(r.Document)
+	for _, def := range []string{
+		"interface Node {\n  id: ID!\n}",
+		"type User implements Node {",
+		"type Address {",
+		"node(id: ID!): Node",
+		"nodes(ids: [ID!]!): [Node]!",
+	} {
+		assert.Contains(t, schema, def)
+	}
+	_, err = gqlparser.LoadSchema(&ast.Source{Name: "schema.genx.graphql", Input: schema})
+	require.NoError(t, err)
+}
+
+func TestEnhanceSchemaAcrossRuns(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+	e := New()
+	for range 2 {
+		require.NoError(t, genx.Generate(context.Background(), &genx.Config{
+			FS:                  fsys,
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/example/app",
+		}, genx.Extensions(e)))
+		assert.Len(t, e.generatedFiles, 1)
+	}
+}
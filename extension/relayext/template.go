@@ -1,10 +1,21 @@
 package relayext
 
 import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/jinzhu/inflection"
+	"github.com/molon/genx"
+	"github.com/pkg/errors"
 	"github.com/samber/lo"
 )
 
@@ -27,3 +38,158 @@ var Funcs = template.FuncMap{
 	"typeString":    TypeString,
 	"isPointerType": IsPointerType,
 }
+
+const (
+	modelsTemplate       = "models.tmpl"
+	rootResolverTemplate = "root_resolver.tmpl"
+	nodeResolverTemplate = "node_resolver.tmpl"
+)
+
+//go:embed embed/*.tmpl
+var embeddedTemplates embed.FS
+
+type Option func(e *Extension)
+
+// WithTemplateDir overrides the embedded templates with the *.tmpl files in dir, relative to the output dir.
+// A file named like an embedded template replaces it, unless it only contains {{ define }} actions
+// in which case only the blocks of the same names are replaced. The other files are available to all templates.
+func WithTemplateDir(dir string) Option {
+	return func(e *Extension) {
+		e.templateDir = dir
+	}
+}
+
+// WithTemplateFS is like WithTemplateDir with the *.tmpl files in the root of fsys.
+func WithTemplateFS(fsys fs.FS) Option {
+	return func(e *Extension) {
+		e.templateFS = fsys
+	}
+}
+
+// WithFuncs registers extra template funcs, they take precedence over Funcs.
+func WithFuncs(funcs template.FuncMap) Option {
+	return func(e *Extension) {
+		if e.funcs == nil {
+			e.funcs = make(template.FuncMap)
+		}
+		maps.Copy(e.funcs, funcs)
+	}
+}
+
+// overrideFS returns the file system of the override templates and its name for the errors, nil if none is configured.
+func (e *Extension) overrideFS(r *genx.Runtime) (fs.FS, string, error) {
+	if e.templateFS != nil {
+		return e.templateFS, "template fs", nil
+	}
+	if e.templateDir == "" {
+		return nil, "", nil
+	}
+	var fsys fs.FS
+	if filepath.IsAbs(e.templateDir) {
+		fsys = os.DirFS(e.templateDir)
+	} else {
+		sub, err := fs.Sub(r.FS, genx.FSName(e.templateDir))
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "invalid template dir %s", e.templateDir)
+		}
+		fsys = sub
+	}
+	if _, err := fs.Stat(fsys, "."); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to open template dir %s", e.templateDir)
+	}
+	return fsys, e.templateDir, nil
+}
+
+// loadTemplates parses the embedded templates and applies the overrides on top of them.
+func (e *Extension) loadTemplates(r *genx.Runtime) (map[string]*template.Template, error) {
+	funcs := e.templateFuncs()
+
+	overrides := make(map[string]string)
+	fsys, dir, err := e.overrideFS(r)
+	if err != nil {
+		return nil, err
+	}
+	if fsys != nil {
+		names, err := fs.Glob(fsys, "*.tmpl")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list templates in %s", dir)
+		}
+		for _, name := range names {
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read template %s", path.Join(dir, name))
+			}
+			overrides[name] = string(data)
+		}
+	}
+
+	entries, err := fs.ReadDir(embeddedTemplates, "embed")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list embedded templates")
+	}
+	templates := make(map[string]*template.Template)
+	for _, entry := range entries {
+		name := entry.Name()
+		if path.Ext(name) != ".tmpl" {
+			continue
+		}
+		data, err := fs.ReadFile(embeddedTemplates, path.Join("embed", name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read embedded template %s", name)
+		}
+		tmpl, err := template.New(name).Funcs(funcs).Parse(string(data))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse template %s", name)
+		}
+		templates[name] = tmpl
+	}
+
+	// the shared files first so that the file named like the template has the last word on its blocks
+	shared := lo.Filter(lo.Keys(overrides), func(name string, _ int) bool {
+		_, ok := templates[name]
+		return !ok
+	})
+	sort.Strings(shared)
+	for name, tmpl := range templates {
+		for _, sharedName := range shared {
+			if _, err := tmpl.New(sharedName).Parse(overrides[sharedName]); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse template %s", path.Join(dir, sharedName))
+			}
+		}
+		if content, ok := overrides[name]; ok {
+			if _, err := tmpl.Parse(content); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse template %s", path.Join(dir, name))
+			}
+		}
+	}
+	return templates, nil
+}
+
+var _ genx.Fingerprinter = (*Extension)(nil)
+
+// Fingerprint covers the layout, the global id format and the override templates. The funcs of WithFuncs
+// cannot be hashed, so incremental runs with them are never skipped as a whole.
+func (e *Extension) Fingerprint(_ context.Context, r *genx.Runtime) (string, error) {
+	if len(e.funcs) > 0 {
+		return "", genx.ErrNoFingerprint
+	}
+	format, err := e.globalIDFormat.resolve()
+	if err != nil {
+		return "", err
+	}
+	fingerprint := string(format)
+	if e.layout != nil {
+		fingerprint += fmt.Sprintf("%+v", *e.layout)
+	}
+	fsys, _, err := e.overrideFS(r)
+	if err != nil || fsys == nil {
+		return fingerprint, err
+	}
+	templates, err := genx.HashFS(fsys, func(name string) bool {
+		return path.Ext(name) == ".tmpl"
+	})
+	if err != nil {
+		return "", err
+	}
+	return fingerprint + templates, nil
+}
@@ -0,0 +1,106 @@
+package relayext
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"text/template"
+
+	"github.com/molon/genx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateWithTemplates(t *testing.T, templates map[string]string, opts ...Option) (*genx.MemFS, error) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+	for name, content := range templates {
+		require.NoError(t, fsys.WriteFile("templates/"+name, []byte(content), 0o644))
+	}
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(New(append([]Option{WithTemplateDir("templates")}, opts...)...)))
+	return fsys, err
+}
+
+func TestTemplateOverrides(t *testing.T) {
+	fsys, err := generateWithTemplates(t, map[string]string{
+		// only redefines a block of the embedded template
+		"node_resolver.tmpl": `{{ define "paginationLimits" }}
+		relay.EnsureLimits[*model.{{ .Name }}](50, 10),{{ end }}`,
+		// shared by all templates
+		"ids.tmpl": `{{ define "generateID" }}func generateID() string {
+	return {{ idPrefix | printf "%q" }} + xid.New().String()
+}{{ end }}`,
+		"models.tmpl": "package model\n\n// {{ len .Nodes }} nodes\n",
+	}, WithFuncs(template.FuncMap{"idPrefix": func() string { return "usr_" }}))
+	require.NoError(t, err)
+
+	resolver, err := fs.ReadFile(fsys, "server/resolver/user_resolver.genx.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(resolver), "relay.EnsureLimits[*model.User](50, 10),")
+	assert.Contains(t, string(resolver), "func (c *UserResolver) batchRead(")
+
+	root, err := fs.ReadFile(fsys, "server/resolver/resolver.genx.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(root), "return \"usr_\" + xid.New().String()")
+	assert.Contains(t, string(root), "func (r *Resolver) Middleware(")
+
+	models, err := fs.ReadFile(fsys, "server/model/models.genx.go")
+	require.NoError(t, err)
+	assert.Equal(t, "// "+header+"package model\n\n// 1 nodes\n", string(models))
+}
+
+func TestTemplateOverridesErrors(t *testing.T) {
+	_, err := generateWithTemplates(t, map[string]string{"models.tmpl": "{{ unknown }}"})
+	assert.ErrorContains(t, err, `failed to parse template templates/models.tmpl: template: models.tmpl:1: function "unknown" not defined`)
+
+	_, err = generateWithTemplates(t, nil, WithTemplateDir("missing"))
+	assert.ErrorContains(t, err, "failed to open template dir missing")
+}
+
+func TestTemplateFuncsIncremental(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+	require.NoError(t, fsys.WriteFile("templates/ids.tmpl", []byte(`{{ define "generateID" }}func generateID() string {
+	return {{ idPrefix | printf "%q" }} + xid.New().String()
+}{{ end }}`), 0o644))
+	generate := func(prefix string) string {
+		err := genx.Generate(context.Background(), &genx.Config{
+			FS:                  fsys,
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/example/app",
+			Incremental:         true,
+		}, genx.Extensions(New(WithTemplateDir("templates"), WithFuncs(template.FuncMap{"idPrefix": func() string { return prefix }}))))
+		require.NoError(t, err)
+		root, err := fs.ReadFile(fsys, "server/resolver/resolver.genx.go")
+		require.NoError(t, err)
+		return string(root)
+	}
+
+	assert.Contains(t, generate("usr_"), `return "usr_" + xid.New().String()`)
+	// the funcs are not fingerprinted, so the run is not skipped
+	assert.Contains(t, generate("user_"), `return "user_" + xid.New().String()`)
+}
+
+func TestGlobalIDFormatIncremental(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+	generate := func(format GlobalIDFormat) string {
+		err := genx.Generate(context.Background(), &genx.Config{
+			FS:                  fsys,
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/example/app",
+			Incremental:         true,
+		}, genx.Extensions(New(WithGlobalIDFormat(format))))
+		require.NoError(t, err)
+		root, err := fs.ReadFile(fsys, "server/resolver/resolver.genx.go")
+		require.NoError(t, err)
+		return string(root)
+	}
+
+	assert.Contains(t, generate(GlobalIDBase64), "encoding/base64")
+	assert.NotContains(t, generate(GlobalIDPrefixed), "encoding/base64")
+}
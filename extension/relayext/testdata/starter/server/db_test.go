@@ -0,0 +1,46 @@
+package server_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/glebarez/sqlite"
+	"github.com/molon/genx/starter/boilerplate/server"
+	"gorm.io/gorm"
+)
+
+// newClient serves the schema from a database opened by newDB.
+func newClient(t *testing.T, models []any, records ...any) *client.Client {
+	t.Helper()
+
+	return client.New(server.NewGQLHandler(newDB(t, models, records...)))
+}
+
+// newDB opens a sqlite database with the tables of models, records are inserted into it.
+func newDB(t *testing.T, models []any, records ...any) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// ids are the ids of the nodes of a connection.
+func ids(nodes []struct{ ID string }) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
@@ -0,0 +1,49 @@
+package server_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/molon/genx/starter/boilerplate/server/model"
+	"github.com/samber/lo"
+)
+
+func TestFilter(t *testing.T) {
+	joined := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	c := newClient(t, []any{&model.Company{}, &model.User{}},
+		&model.Company{ID: "acme", Name: "Acme"},
+		&model.Company{ID: "globex", Name: "Globex"},
+		&model.User{ID: "alice", Name: lo.ToPtr("Alice"), Age: 30, JoinedAt: joined(2024, 1, 15), CompanyID: "acme"},
+		&model.User{ID: "bob", Name: lo.ToPtr("Bob"), Age: 40, JoinedAt: joined(2023, 6, 1), CompanyID: "acme"},
+		&model.User{ID: "carol", Age: 50, JoinedAt: joined(2024, 3, 1), CompanyID: "globex"},
+		&model.User{ID: "dave", Name: lo.ToPtr("Dave"), Age: 9, JoinedAt: joined(2022, 1, 1), CompanyID: "globex"},
+		&model.User{ID: "erin", Name: lo.ToPtr("Erin"), Age: 10, JoinedAt: joined(2024, 2, 1), CompanyID: "globex"},
+	)
+
+	// the operands are typed, so 10 is greater than 9 unlike as strings
+	for filterBy, expected := range map[string][]string{
+		`{age: {gt: 35}}`:                                                      {"bob", "carol"},
+		`{age: {gt: 9}}`:                                                       {"erin", "alice", "bob", "carol"},
+		`{age: {in: [9, 10]}}`:                                                 {"dave", "erin"},
+		`{name: {equals: "alice", fold: true}}`:                                {"alice"},
+		`{name: {startsWith: "B"}}`:                                            {"bob"},
+		`{name: {isNull: true}}`:                                               {"carol"},
+		`{company: {name: {equals: "Acme"}}}`:                                  {"alice", "bob"},
+		`{not: {company: {name: {equals: "Acme"}}}}`:                           {"dave", "erin", "carol"},
+		`{or: [{age: {lt: 35}}, {age: {gte: 50}}]}`:                            {"dave", "erin", "alice", "carol"},
+		`{and: [{age: {gte: 30}}, {age: {lte: 40}}]}`:                          {"alice", "bob"},
+		`{joinedAt: {gte: "2024-01-01T00:00:00Z"}}`:                            {"erin", "alice", "carol"},
+		`{joinedAt: {gt: "2023-01-01T00:00:00Z", lt: "2024-02-01T00:00:00Z"}}`: {"alice", "bob"},
+	} {
+		var resp struct {
+			Users struct{ Nodes []struct{ ID string } }
+		}
+		c.MustPost(`query { users(filterBy: `+filterBy+`, orderBy: [{field: AGE, direction: ASC}]) { nodes { id } } }`, &resp)
+		if actual := ids(resp.Users.Nodes); !slices.Equal(actual, expected) {
+			t.Errorf("users filtered by %s are %v, want %v", filterBy, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package server_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/molon/genx/starter/boilerplate/server/model"
+	"github.com/samber/lo"
+)
+
+func TestManyToMany(t *testing.T) {
+	c := newClient(t, []any{&model.Post{}, &model.Tag{}, &model.PostTag{}},
+		&model.Post{ID: "intro", Title: "Intro"},
+		&model.Post{ID: "joins", Title: "Joins"},
+		&model.Tag{ID: "go", Name: "go"},
+		&model.Tag{ID: "sql", Name: "sql"},
+	)
+
+	c.MustPost(`mutation {
+  a: addTagToPost(input: {postId: "intro", tagId: "go", note: "main"}) { tag { id } }
+  b: addTagToPost(input: {postId: "intro", tagId: "sql"}) { tag { id } }
+  c: addTagToPost(input: {postId: "joins", tagId: "sql", note: "only"}) { tag { id } }
+  d: removeTagFromPost(input: {postId: "intro", tagId: "sql"}) { tag { id } }
+}`, &map[string]any{})
+
+	var posts struct {
+		Posts struct {
+			Nodes []struct {
+				ID   string
+				Tags struct {
+					Edges []struct {
+						Node struct{ ID string }
+						Note *string
+					}
+				}
+			}
+		}
+	}
+	c.MustPost(`query {
+  posts(orderBy: [{field: TITLE, direction: ASC}]) {
+    nodes { id tags(orderBy: [{field: NAME, direction: ASC}]) { edges { node { id } note } } }
+  }
+}`, &posts)
+	var actual []string
+	for _, post := range posts.Posts.Nodes {
+		for _, edge := range post.Tags.Edges {
+			actual = append(actual, post.ID+" "+edge.Node.ID+" "+lo.FromPtr(edge.Note))
+		}
+	}
+	if expected := []string{"intro go main", "joins sql only"}; !slices.Equal(actual, expected) {
+		t.Errorf("tags of the posts are %v, want %v", actual, expected)
+	}
+
+	var tags struct {
+		Tags struct {
+			Nodes []struct {
+				ID    string
+				Posts struct{ Nodes []struct{ ID string } }
+			}
+		}
+	}
+	c.MustPost(`query {
+  tags(filterBy: {name: {equals: "sql"}}) { nodes { id posts(filterBy: {title: {startsWith: "J"}}) { nodes { id } } } }
+}`, &tags)
+	actual = nil
+	for _, tag := range tags.Tags.Nodes {
+		for _, id := range ids(tag.Posts.Nodes) {
+			actual = append(actual, tag.ID+" "+id)
+		}
+	}
+	if expected := []string{"sql joins"}; !slices.Equal(actual, expected) {
+		t.Errorf("posts of the tags are %v, want %v", actual, expected)
+	}
+}
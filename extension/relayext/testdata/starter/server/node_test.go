@@ -0,0 +1,34 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/molon/genx/starter/boilerplate/server/model"
+)
+
+func TestNode(t *testing.T) {
+	c := newClient(t, []any{&model.UserProfile{}})
+
+	var created struct {
+		CreateUserProfile struct{ UserProfile struct{ ID string } }
+	}
+	c.MustPost(`mutation { createUserProfile(input: {name: "alice"}) { userProfile { id } } }`, &created)
+	id := created.CreateUserProfile.UserProfile.ID
+
+	// the type of the node is decoded from its global id
+	var resp struct {
+		Node  struct{ ID, Name string }
+		Nodes []struct{ ID, Name string }
+	}
+	c.MustPost(`query($id: ID!) {
+  node(id: $id) { id ... on UserProfile { name } }
+  nodes(ids: [$id]) { id ... on UserProfile { name } }
+}`, &resp, client.Var("id", id))
+	if resp.Node.ID != id || resp.Node.Name != "alice" {
+		t.Errorf("node %s is %+v", id, resp.Node)
+	}
+	if len(resp.Nodes) != 1 || resp.Nodes[0] != resp.Node {
+		t.Errorf("nodes [%s] are %+v", id, resp.Nodes)
+	}
+}
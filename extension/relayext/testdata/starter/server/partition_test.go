@@ -0,0 +1,75 @@
+package server_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/molon/genx/starter/boilerplate/server"
+	"github.com/molon/genx/starter/boilerplate/server/model"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+func TestPartitions(t *testing.T) {
+	db := newDB(t, []any{&model.Company{}, &model.User{}},
+		&model.Company{ID: "acme"},
+		&model.Company{ID: "globex"},
+		&model.Company{ID: "initech"},
+		&model.User{ID: "alice", Age: 30, CompanyID: lo.ToPtr("acme")},
+		&model.User{ID: "bob", Age: 40, CompanyID: lo.ToPtr("acme")},
+		&model.User{ID: "carol", Age: 50, CompanyID: lo.ToPtr("acme")},
+		&model.User{ID: "dave", Age: 20, CompanyID: lo.ToPtr("globex")},
+		&model.User{ID: "frank", Age: 35, CompanyID: lo.ToPtr("globex")},
+		&model.User{ID: "erin", Age: 60},
+	)
+	var partitions int
+	if err := db.Callback().Query().After("gorm:query").Register("test:partitions", func(db *gorm.DB) {
+		if !db.DryRun && strings.Contains(db.Statement.SQL.String(), "ROW_NUMBER() OVER") {
+			partitions++
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c := client.New(server.NewGQLHandler(db))
+
+	var resp struct {
+		Companies struct {
+			Nodes []struct {
+				ID        string
+				Employees struct {
+					Nodes      []struct{ ID string }
+					TotalCount int
+					PageInfo   struct{ HasNextPage bool }
+				}
+			}
+		}
+	}
+	c.MustPost(`query {
+  companies {
+    nodes {
+      id
+      employees(first: 2, filterBy: {age: {gte: 30}}, orderBy: [{field: AGE, direction: DESC}]) {
+        nodes { id }
+        totalCount
+        pageInfo { hasNextPage }
+      }
+    }
+  }
+}`, &resp)
+	var actual []string
+	for _, company := range resp.Companies.Nodes {
+		employees := company.Employees
+		actual = append(actual, fmt.Sprintf("%s %v %d %t", company.ID, ids(employees.Nodes), employees.TotalCount, employees.PageInfo.HasNextPage))
+	}
+	expected := []string{"acme [carol bob] 3 true", "globex [frank] 1 false", "initech [] 0 false"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("employees of the companies are %v, want %v", actual, expected)
+	}
+	// the pages of the companies with employees are loaded by one query limited per company with a window function
+	if partitions != 1 {
+		t.Errorf("employees are loaded by %d partition queries, want 1", partitions)
+	}
+}
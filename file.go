@@ -2,6 +2,7 @@ package genx
 
 import (
 	"context"
+	"go/scanner"
 	"path/filepath"
 
 	"github.com/pkg/errors"
@@ -10,12 +11,14 @@ import (
 type File struct {
 	RelPath string
 	Content string
+	// Merge opts in to combine the file with the files of the same path generated by other extensions
+	Merge MergeStrategy
 }
 
 func (f *File) Format(ctx context.Context) error {
 	content, err := FormatText(ctx, filepath.Ext(f.RelPath), f.Content)
 	if err != nil {
-		return errors.Wrapf(err, "failed to format %s", f.RelPath)
+		return errors.Wrapf(f.positionErrors(err), "failed to format %s", f.RelPath)
 	}
 	f.Content = content
 	return nil
@@ -28,8 +31,23 @@ func (f *File) ApplyReplacements(ctx context.Context, replacements Replacements)
 	}
 	content, err := FormatText(ctx, filepath.Ext(f.RelPath), text)
 	if err != nil {
-		return errors.Wrapf(err, "failed to format %s after applying replacements", f.RelPath)
+		return errors.Wrapf(f.positionErrors(err), "failed to format %s after applying replacements", f.RelPath)
 	}
 	f.Content = content
 	return nil
 }
+
+// positionErrors points the syntax errors and the panics of the formatters to the file.
+func (f *File) positionErrors(err error) error {
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		for _, e := range list {
+			e.Pos.Filename = f.RelPath
+		}
+	}
+	var p *formatterPanicError
+	if errors.As(err, &p) {
+		return &Diagnostic{Severity: SeverityError, Message: p.Error(), File: f.RelPath, Err: err}
+	}
+	return err
+}
@@ -1,26 +1,191 @@
 package genx
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 
+	"github.com/molon/genx/pkg/gqlx"
 	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/imports"
+	"gopkg.in/yaml.v3"
 	"mvdan.cc/gofumpt/format"
 )
 
-func FormatText(_ context.Context, ext, text string) (string, error) {
-	switch ext {
-	case ".go":
-		formatted, err := FormatGo(text)
+// Formatter normalizes the content of the generated files with a given extension.
+type Formatter interface {
+	Format(ctx context.Context, text string) (string, error)
+}
+
+type FormatterFunc func(ctx context.Context, text string) (string, error)
+
+func (f FormatterFunc) Format(ctx context.Context, text string) (string, error) {
+	return f(ctx, text)
+}
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = map[string]Formatter{
+		".go":       &GoFormatter{},
+		".graphql":  FormatterFunc(FormatGraphQL),
+		".graphqls": FormatterFunc(FormatGraphQL),
+		".json":     FormatterFunc(FormatJSON),
+		".yaml":     FormatterFunc(FormatYAML),
+		".yml":      FormatterFunc(FormatYAML),
+		".sql":      FormatterFunc(FormatPlainText),
+		".ts":       FormatterFunc(FormatPlainText),
+	}
+)
+
+// RegisterFormatter sets the formatter of the files with the given extension, e.g. ".ts",
+// replacing the built-in one if any, a nil formatter leaves these files unformatted.
+func RegisterFormatter(ext string, f Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	if f == nil {
+		delete(formatters, ext)
+		return
+	}
+	formatters[ext] = f
+}
+
+type formattersKey struct{}
+
+// withFormatters makes FormatText prefer the given formatters over the registered ones.
+func withFormatters(ctx context.Context, overrides map[string]Formatter) context.Context {
+	return context.WithValue(ctx, formattersKey{}, overrides)
+}
+
+func lookupFormatter(ctx context.Context, ext string) Formatter {
+	if overrides, ok := ctx.Value(formattersKey{}).(map[string]Formatter); ok {
+		if f, ok := overrides[ext]; ok {
+			return f
+		}
+	}
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	return formatters[ext]
+}
+
+// FormatText formats text with the formatter of ext, the text is returned unchanged if there is none.
+func FormatText(ctx context.Context, ext, text string) (string, error) {
+	f := lookupFormatter(ctx, ext)
+	if f == nil {
+		return text, nil
+	}
+	cache, _ := ctx.Value(formatCacheKey{}).(*FormatCache)
+	return cache.do(cache.key(f, ext, text), func() (formatted string, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				formatted, err = "", &formatterPanicError{ext: ext, err: panicError(p)}
+			}
+		}()
+		formatted, err = f.Format(ctx, text)
 		if err != nil {
 			return "", errors.Wrapf(err, "failed to format %s", ext)
 		}
 		return formatted, nil
+	})
+}
+
+// formatterPanicError is returned by FormatText when the formatter panics, the waiters of the cache get it as well.
+type formatterPanicError struct {
+	ext string
+	err error
+}
+
+func (e *formatterPanicError) Error() string {
+	return fmt.Sprintf("formatter of %s panicked: %v", e.ext, e.err)
+}
+
+func (e *formatterPanicError) Unwrap() error {
+	return e.err
+}
+
+// FormatCache remembers the outputs of the formatters by formatter and input, so that the targets of a run
+// sharing it format identical files once, even at the same time.
+type FormatCache struct {
+	mu      sync.Mutex
+	entries map[string]*formatEntry
+}
+
+type formatEntry struct {
+	done      chan struct{}
+	formatted string
+	err       error
+}
+
+func NewFormatCache() *FormatCache {
+	return &FormatCache{entries: make(map[string]*formatEntry)}
+}
+
+type formatCacheKey struct{}
+
+func withFormatCache(ctx context.Context, cache *FormatCache) context.Context {
+	return context.WithValue(ctx, formatCacheKey{}, cache)
+}
+
+// key identifies the formatter by its type and value, e.g. the LangVersion of a GoFormatter.
+func (c *FormatCache) key(f Formatter, ext, text string) string {
+	if c == nil {
+		return ""
+	}
+	return ext + "\x00" + fmt.Sprintf("%T%v", f, f) + "\x00" + Hash([]byte(text))
+}
+
+// do returns the result of fn for key, fn is called once while the other callers wait for it.
+// fn must not panic, FormatText recovers the panics of the formatters.
+func (c *FormatCache) do(key string, fn func() (string, error)) (string, error) {
+	if c == nil {
+		return fn()
 	}
-	return text, nil
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &formatEntry{done: make(chan struct{})}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+	if !ok {
+		e.formatted, e.err = fn()
+		close(e.done)
+	}
+	<-e.done
+	return e.formatted, e.err
+}
+
+const defaultGoLangVersion = "go1.23.0"
+
+// GoFormatter formats Go sources with goimports and gofumpt.
+type GoFormatter struct {
+	// LangVersion is the Go version of the target module, e.g. go1.23.0
+	LangVersion string
+}
+
+func (f *GoFormatter) Format(_ context.Context, text string) (string, error) {
+	return formatGo(text, f.LangVersion)
 }
 
 func FormatGo(source string) (string, error) {
+	return formatGo(source, "")
+}
+
+func formatGo(source, langVersion string) (string, error) {
+	if langVersion == "" {
+		langVersion = defaultGoLangVersion
+	}
 	formatted, err := imports.Process("dummy.go", []byte(source), &imports.Options{
 		AllErrors:  false,
 		Comments:   true,
@@ -31,10 +196,133 @@ func FormatGo(source string) (string, error) {
 	}
 
 	formatted, err = format.Source(formatted, format.Options{
-		LangVersion: "go1.23.0",
+		LangVersion: langVersion,
 	})
 	if err != nil {
 		return "", errors.Wrap(err, "failed to format Go source with gofumpt")
 	}
 	return string(formatted), nil
 }
+
+// GoLangVersion returns the Go version declared in the go.mod of the module containing the root of fsys,
+// the parent directories are searched for file systems backed by a directory.
+func GoLangVersion(fsys fs.FS) (string, error) {
+	data, err := fs.ReadFile(fsys, "go.mod")
+	if errors.Is(err, fs.ErrNotExist) {
+		if dirFS, ok := fsys.(DirFS); ok {
+			data, err = readParentGoMod(dirFS.Dir())
+		}
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read go.mod")
+	}
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse go.mod")
+	}
+	if f.Go == nil {
+		return "", errors.New("no go directive in go.mod")
+	}
+	return "go" + f.Go.Version, nil
+}
+
+func readParentGoMod(dir string) ([]byte, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fs.ErrNotExist
+		}
+		dir = parent
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if !errors.Is(err, fs.ErrNotExist) {
+			return data, err
+		}
+	}
+}
+
+// FormatGraphQL formats a schema, or an executable document if the text is not a schema.
+func FormatGraphQL(_ context.Context, text string) (string, error) {
+	formatted, err := gqlx.FormatSchema(&ast.Source{Input: text})
+	if err == nil {
+		return formatted, nil
+	}
+	doc, queryErr := parser.ParseQuery(&ast.Source{Input: text})
+	if queryErr != nil {
+		return "", err
+	}
+	sb := &strings.Builder{}
+	formatter.NewFormatter(sb, formatter.WithIndent("  "), formatter.WithComments()).FormatQueryDocument(doc)
+	return sb.String(), nil
+}
+
+func FormatJSON(_ context.Context, text string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace([]byte(text)), "", "  "); err != nil {
+		return "", errors.Wrap(err, "failed to format JSON")
+	}
+	buf.WriteByte('\n')
+	return buf.String(), nil
+}
+
+// FormatYAML re-encodes every document of the stream with an indent of two spaces, comments are kept.
+func FormatYAML(_ context.Context, text string) (string, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(text))
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", errors.Wrap(err, "failed to parse YAML")
+		}
+		if err := encoder.Encode(&node); err != nil {
+			return "", errors.Wrap(err, "failed to format YAML")
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return "", errors.Wrap(err, "failed to format YAML")
+	}
+	return buf.String(), nil
+}
+
+var reBlankLines = regexp.MustCompile(`\n{3,}`)
+
+// FormatPlainText normalizes the whitespace of languages without a Go formatter: line endings,
+// trailing spaces, runs of blank lines and the final newline. Register a formatter for a full one.
+func FormatPlainText(_ context.Context, text string) (string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	text = strings.Trim(strings.Join(lines, "\n"), "\n")
+	text = reBlankLines.ReplaceAllString(text, "\n\n")
+	if text == "" {
+		return "", nil
+	}
+	return text + "\n", nil
+}
+
+// formatters returns the formatters of the run, the Go version of the built-in Go formatter
+// is taken from the go.mod of the target module.
+func (r *Runtime) formatters(ctx context.Context) map[string]Formatter {
+	overrides := make(map[string]Formatter, len(r.Config.Formatters)+1)
+	for ext, f := range r.Config.Formatters {
+		overrides[ext] = f
+	}
+	if _, ok := overrides[".go"]; !ok {
+		if f, ok := lookupFormatter(ctx, ".go").(*GoFormatter); ok && f.LangVersion == "" {
+			if langVersion, err := GoLangVersion(r.FS); err == nil {
+				overrides[".go"] = &GoFormatter{LangVersion: langVersion}
+			}
+		}
+	}
+	return overrides
+}
@@ -1,6 +1,10 @@
 package genx
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -68,3 +72,65 @@ func main() {
 		}
 	}
 }
+
+func TestFormatText(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		ext  string
+		text string
+		want string
+	}{
+		{".json", `{"b":1,"a":[1,2]}`, "{\n  \"b\": 1,\n  \"a\": [\n    1,\n    2\n  ]\n}\n"},
+		{".yaml", "a:\n    b: 1 # one\n    c: [1, 2]\n", "a:\n  b: 1 # one\n  c: [1, 2]\n"},
+		{".sql", "SELECT 1;  \r\n\n\n\nSELECT 2;", "SELECT 1;\n\nSELECT 2;\n"},
+		{".graphql", "# header\n\ntype A {\n    a: Int }\n\n\n\"desc\"\nscalar B", "# header\n\ntype A {\n  a: Int\n}\n\n\"\"\"\ndesc\n\"\"\"\nscalar B\n"},
+		{".graphql", "query { a }", "query {\n  a\n}\n"},
+		{".txt", "unchanged  \n", "unchanged  \n"},
+	} {
+		got, err := FormatText(ctx, tc.ext, tc.text)
+		require.NoError(t, err, tc.ext)
+		assert.Equal(t, tc.want, got, tc.ext)
+
+		// idempotent
+		again, err := FormatText(ctx, tc.ext, got)
+		require.NoError(t, err, tc.ext)
+		assert.Equal(t, got, again, tc.ext)
+	}
+
+	_, err := FormatText(ctx, ".json", `{`)
+	require.ErrorContains(t, err, "failed to format .json")
+}
+
+func TestFormatTextOverrides(t *testing.T) {
+	upper := FormatterFunc(func(_ context.Context, text string) (string, error) {
+		return strings.ToUpper(text), nil
+	})
+	ctx := withFormatters(context.Background(), map[string]Formatter{".txt": upper, ".json": nil})
+
+	got, err := FormatText(ctx, ".txt", "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "ABC", got)
+
+	got, err = FormatText(ctx, ".json", `{"a":1}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, got)
+}
+
+func TestGoLangVersion(t *testing.T) {
+	fsys := NewMemFS()
+	_, err := GoLangVersion(fsys)
+	require.Error(t, err)
+
+	require.NoError(t, fsys.WriteFile("go.mod", []byte("module example.com/app\n\ngo 1.22.5\n"), 0o644))
+	version, err := GoLangVersion(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, "go1.22.5", version)
+
+	// the module root is searched upwards from a sub directory
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.21\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	version, err = GoLangVersion(OSFS(filepath.Join(dir, "sub")))
+	require.NoError(t, err)
+	assert.Equal(t, "go1.21", version)
+}
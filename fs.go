@@ -0,0 +1,239 @@
+package genx
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FS is a writable file system, names are slash separated and relative to its root as in io/fs.
+// WriteFile keeps the permissions of an existing file, perm only applies to new files.
+type FS interface {
+	fs.FS
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Remove(name string) error
+	MkdirAll(name string, perm fs.FileMode) error
+}
+
+// DirFS is implemented by the file systems backed by a directory on the OS file system.
+// Runtime.FS implements it for an output dir on the OS file system, its staged content is not in Dir until committed.
+type DirFS interface {
+	FS
+	Dir() string
+}
+
+// FSName converts a native relative path to a name that can be used with FS.
+func FSName(relPath string) string {
+	return path.Clean(filepath.ToSlash(relPath))
+}
+
+var _ DirFS = (*osFS)(nil)
+
+type osFS struct {
+	fs.FS
+	dir string
+}
+
+// OSFS returns a file system backed by the OS file system rooted at dir.
+func OSFS(dir string) DirFS {
+	return &osFS{FS: os.DirFS(dir), dir: dir}
+}
+
+func (f *osFS) Dir() string {
+	return f.dir
+}
+
+func (f *osFS) path(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(f.dir, filepath.FromSlash(name)), nil
+}
+
+// WriteFile replaces the file atomically by renaming a temporary file over it,
+// an existing file keeps its permissions and perm is used for new files.
+func (f *osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	p, err := f.path(name)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(p)
+	switch {
+	case err == nil && info.Mode()&fs.ModeSymlink != 0:
+		// renaming would replace the link itself
+		return os.WriteFile(p, data, perm)
+	case err == nil:
+		perm = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if err := writeTemp(tmp, data, perm); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func writeTemp(tmp *os.File, data []byte, perm fs.FileMode) error {
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	return tmp.Close()
+}
+
+func (f *osFS) Remove(name string) error {
+	p, err := f.path(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+func (f *osFS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := f.path(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(p, perm)
+}
+
+var _ FS = (*MemFS)(nil)
+
+// MemFS is an in-memory FS which is safe for concurrent use.
+type MemFS struct {
+	mu    sync.RWMutex
+	files fstest.MapFS
+}
+
+func NewMemFS() *MemFS {
+	return &MemFS{files: make(fstest.MapFS)}
+}
+
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.Open(name)
+}
+
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, ok := m.files[name]; ok {
+		if f.Mode.IsDir() {
+			return &fs.PathError{Op: "write", Path: name, Err: errors.New("is a directory")}
+		}
+		perm = f.Mode
+	}
+	m.files[name] = &fstest.MapFile{
+		Data:    append([]byte(nil), data...),
+		Mode:    perm.Perm(),
+		ModTime: time.Now(),
+	}
+	return nil
+}
+
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	prefix := name + "/"
+	for k := range m.files {
+		if strings.HasPrefix(k, prefix) {
+			return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		if f, ok := m.files[dir]; ok {
+			if !f.Mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: dir, Err: errors.New("not a directory")}
+			}
+			continue
+		}
+		m.files[dir] = &fstest.MapFile{Mode: fs.ModeDir | perm.Perm(), ModTime: time.Now()}
+	}
+	return nil
+}
+
+// Names returns the sorted names of all regular files.
+func (m *MemFS) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var names []string
+	for name, f := range m.files {
+		if !f.Mode.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CopyFS copies all regular files of src into dst, skipping directories for which skip returns true.
+func CopyFS(dst FS, src fs.FS, skip func(name string, d fs.DirEntry) bool) error {
+	return fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if skip != nil && name != "." && skip(name, d) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return dst.MkdirAll(name, info.Mode().Perm()|0o700)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+		if err := dst.WriteFile(name, data, info.Mode().Perm()); err != nil {
+			return errors.Wrapf(err, "failed to write %s", name)
+		}
+		return nil
+	})
+}
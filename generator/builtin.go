@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"github.com/molon/genx"
+	"github.com/molon/genx/extension/cleanup"
+	"github.com/molon/genx/extension/gosurgery"
+	"github.com/molon/genx/extension/gqlgenext"
+	"github.com/molon/genx/extension/relayext"
+)
+
+func init() {
+	Register("relayext", func(options map[string]any) (genx.Extension, error) {
+		var opts struct {
+			// TemplateDir is relative to the output dir
+			TemplateDir string `mapstructure:"templateDir"`
+			// Layout takes the fields of relayext.Layout in camel case, e.g. modelDir
+			Layout *relayext.Layout `mapstructure:"layout"`
+			// GlobalIDFormat is base64 or prefixed
+			GlobalIDFormat relayext.GlobalIDFormat `mapstructure:"globalIdFormat"`
+		}
+		if err := DecodeOptions(options, &opts); err != nil {
+			return nil, err
+		}
+		var relayOptions []relayext.Option
+		if opts.TemplateDir != "" {
+			relayOptions = append(relayOptions, relayext.WithTemplateDir(opts.TemplateDir))
+		}
+		if opts.Layout != nil {
+			relayOptions = append(relayOptions, relayext.WithLayout(opts.Layout))
+		}
+		if opts.GlobalIDFormat != "" {
+			relayOptions = append(relayOptions, relayext.WithGlobalIDFormat(opts.GlobalIDFormat))
+		}
+		return relayext.New(relayOptions...), nil
+	})
+	Register("gosurgery", func(options map[string]any) (genx.Extension, error) {
+		if err := DecodeOptions(options, &struct{}{}); err != nil {
+			return nil, err
+		}
+		return gosurgery.New(), nil
+	})
+	Register("gqlgenext", func(options map[string]any) (genx.Extension, error) {
+		if err := DecodeOptions(options, &struct{}{}); err != nil {
+			return nil, err
+		}
+		return gqlgenext.New(), nil
+	})
+	Register("cleanup", func(options map[string]any) (genx.Extension, error) {
+		if err := DecodeOptions(options, &struct{}{}); err != nil {
+			return nil, err
+		}
+		return cleanup.New(), nil
+	})
+}
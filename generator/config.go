@@ -0,0 +1,153 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/molon/genx/pkg/configx"
+	"github.com/pkg/errors"
+)
+
+const DefaultConfigFile = "genx.yaml"
+
+type ExtensionConfig struct {
+	Name    string         `mapstructure:"name" validate:"required"`
+	Options map[string]any `mapstructure:"options"`
+
+	// Command runs the extension as a subprocess speaking the execext protocol instead of a registered extension
+	Command []string `mapstructure:"command"`
+	// Dir is the working directory of Command, resolved relative to the directory of the config file,
+	// defaults to the output dir
+	Dir        string   `mapstructure:"dir"`
+	Env        []string `mapstructure:"env"`
+	Phases     []string `mapstructure:"phases" validate:"dive,oneof=beforeGenerate generate afterGenerate"`
+	DependsOn  []string `mapstructure:"dependsOn"`
+	RunsBefore []string `mapstructure:"runsBefore"`
+	RunsAfter  []string `mapstructure:"runsAfter"`
+}
+
+// SourceConfig selects prototype files relative to the output dir, see genx.SchemaSource.
+type SourceConfig struct {
+	Patterns      []string `mapstructure:"patterns" validate:"required,min=1"`
+	Excludes      []string `mapstructure:"excludes"`
+	Introspection bool     `mapstructure:"introspection"`
+}
+
+// TargetConfig is a target generated in the same run as the others, e.g. a service of a monorepo.
+// The prototype, module and extensions left empty are those of the Config.
+type TargetConfig struct {
+	Name string `mapstructure:"name" validate:"required"`
+	// OutputDir is resolved relative to the directory of the config file
+	OutputDir           string             `mapstructure:"outputDir" validate:"required"`
+	PrototypeRelPattern string             `mapstructure:"prototypeRelPattern"`
+	Sources             []*SourceConfig    `mapstructure:"sources" validate:"dive,required"`
+	GoModule            string             `mapstructure:"goModule"`
+	Extensions          []*ExtensionConfig `mapstructure:"extensions" validate:"dive,required"`
+}
+
+type Config struct {
+	// Target is the name of the target this config was derived from, see Targets
+	Target string `mapstructure:"-"`
+	// OutputDir is resolved relative to the directory of the config file, defaults to that directory
+	OutputDir           string             `mapstructure:"outputDir"`
+	PrototypeRelPattern string             `mapstructure:"prototypeRelPattern" validate:"required_without_all=Sources Targets"`
+	Sources             []*SourceConfig    `mapstructure:"sources" validate:"dive,required"`
+	GoModule            string             `mapstructure:"goModule" validate:"required_without=Targets"`
+	Extensions          []*ExtensionConfig `mapstructure:"extensions" validate:"required_without=Targets,dive,required"`
+	Concurrency         int                `mapstructure:"concurrency"`
+	Incremental         bool               `mapstructure:"incremental"`
+	// WatchDebounce is how long watch mode waits for more changes before regenerating
+	WatchDebounce time.Duration `mapstructure:"watchDebounce"`
+	// Targets are generated concurrently in one run instead of OutputDir
+	Targets []*TargetConfig `mapstructure:"targets" validate:"unique=Name,dive,required"`
+}
+
+// TargetConfigs returns a config for every target with the inherited fields filled in,
+// or the config itself if it has no targets.
+func (c *Config) TargetConfigs() []*Config {
+	if len(c.Targets) == 0 {
+		return []*Config{c}
+	}
+	confs := make([]*Config, 0, len(c.Targets))
+	for _, t := range c.Targets {
+		conf := *c
+		conf.Targets = nil
+		conf.Target = t.Name
+		conf.OutputDir = t.OutputDir
+		if t.PrototypeRelPattern != "" || len(t.Sources) > 0 {
+			conf.PrototypeRelPattern = t.PrototypeRelPattern
+			conf.Sources = t.Sources
+		}
+		if t.GoModule != "" {
+			conf.GoModule = t.GoModule
+		}
+		if len(t.Extensions) > 0 {
+			conf.Extensions = t.Extensions
+		}
+		confs = append(confs, &conf)
+	}
+	return confs
+}
+
+func validateTargets(conf *Config) error {
+	for _, c := range conf.TargetConfigs() {
+		switch {
+		case c.PrototypeRelPattern == "" && len(c.Sources) == 0:
+			return errors.Errorf("target %s has neither a prototypeRelPattern nor sources", c.Target)
+		case c.GoModule == "":
+			return errors.Errorf("target %s has no goModule", c.Target)
+		case len(c.Extensions) == 0:
+			return errors.Errorf("target %s has no extensions", c.Target)
+		}
+	}
+	return nil
+}
+
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		path = DefaultConfigFile
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open config %s", path)
+	}
+	defer f.Close()
+
+	conf, err := configx.Read[*Config](filepath.Ext(path), f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read config %s", path)
+	}
+	if conf == nil {
+		return nil, errors.Errorf("empty config %s", path)
+	}
+
+	if err := validator.New(validator.WithRequiredStructEnabled()).Struct(conf); err != nil {
+		return nil, errors.Wrapf(err, "validation failed for config %s", path)
+	}
+
+	if err := validateTargets(conf); err != nil {
+		return nil, errors.Wrapf(err, "validation failed for config %s", path)
+	}
+
+	resolve := func(dir string) string {
+		if filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(filepath.Dir(path), dir)
+	}
+	conf.OutputDir = resolve(conf.OutputDir)
+	extensions := conf.Extensions
+	for _, t := range conf.Targets {
+		t.OutputDir = resolve(t.OutputDir)
+		extensions = append(extensions, t.Extensions...)
+	}
+	for _, ext := range extensions {
+		if ext.Dir != "" {
+			ext.Dir = resolve(ext.Dir)
+		}
+	}
+	return conf, nil
+}
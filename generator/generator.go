@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"context"
+
+	"github.com/molon/genx"
+	"github.com/pkg/errors"
+)
+
+// Generate runs the pipeline, the targets of conf if any are generated concurrently.
+func Generate(ctx context.Context, conf *Config, options ...genx.Option) error {
+	if len(conf.Targets) > 0 {
+		configs, err := newGenxConfigs(conf)
+		if err != nil {
+			return err
+		}
+		if err := genx.GenerateTargets(ctx, configs, options...); err != nil {
+			return errors.Wrap(err, "failed to generate")
+		}
+		return nil
+	}
+	config, err := newGenxConfig(conf)
+	if err != nil {
+		return err
+	}
+	if err := genx.Generate(ctx, config, options...); err != nil {
+		return errors.Wrap(err, "failed to generate")
+	}
+	return nil
+}
+
+// Check reports the generated files that are out of date, with a report per target.
+func Check(ctx context.Context, conf *Config, options ...genx.Option) ([]*genx.CheckReport, error) {
+	if len(conf.Targets) > 0 {
+		configs, err := newGenxConfigs(conf)
+		if err != nil {
+			return nil, err
+		}
+		reports, err := genx.CheckTargets(ctx, configs, options...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to check")
+		}
+		return reports, nil
+	}
+	config, err := newGenxConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	report, err := genx.Check(ctx, config, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check")
+	}
+	return []*genx.CheckReport{report}, nil
+}
+
+func newGenxConfigs(conf *Config) ([]*genx.Config, error) {
+	var configs []*genx.Config
+	for _, c := range conf.TargetConfigs() {
+		config, err := newGenxConfig(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "target %s", c.Target)
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+func newGenxConfig(conf *Config) (*genx.Config, error) {
+	extensions, err := NewExtensions(conf.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	return &genx.Config{
+		Target:              conf.Target,
+		OutputDir:           conf.OutputDir,
+		PrototypeRelPattern: conf.PrototypeRelPattern,
+		Sources:             schemaSources(conf),
+		GoModule:            conf.GoModule,
+		Extensions:          extensions,
+		Concurrency:         conf.Concurrency,
+		Incremental:         conf.Incremental,
+	}, nil
+}
+
+func schemaSources(conf *Config) []*genx.SchemaSource {
+	sources := make([]*genx.SchemaSource, 0, len(conf.Sources))
+	for _, src := range conf.Sources {
+		sources = append(sources, &genx.SchemaSource{
+			Patterns:      src.Patterns,
+			Excludes:      src.Excludes,
+			Introspection: src.Introspection,
+		})
+	}
+	return sources
+}
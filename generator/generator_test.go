@@ -0,0 +1,164 @@
+package generator_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "genx.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+prototypeRelPattern: prototype.graphql
+goModule: github.com/molon/genx/example
+extensions:
+  - name: relayext
+  - name: gosurgery
+  - name: gqlgenext
+  - name: cleanup
+`), 0o644))
+
+	conf, err := generator.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, dir, conf.OutputDir)
+	assert.Equal(t, "prototype.graphql", conf.PrototypeRelPattern)
+	assert.Equal(t, "github.com/molon/genx/example", conf.GoModule)
+
+	extensions, err := generator.NewExtensions(conf.Extensions)
+	require.NoError(t, err)
+	names := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		names = append(names, ext.Name())
+	}
+	assert.Equal(t, []string{"relayext", "gosurgery", "gqlgenext", "cleanup"}, names)
+}
+
+func TestLoadConfigSources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "genx.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+sources:
+  - patterns: ["schema/**/*.graphql"]
+    excludes: ["schema/drafts/**"]
+  - patterns: [remote.json]
+    introspection: true
+goModule: github.com/molon/genx/example
+extensions:
+  - name: relayext
+`), 0o644))
+
+	conf, err := generator.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Empty(t, conf.PrototypeRelPattern)
+	assert.Equal(t, []*generator.SourceConfig{
+		{Patterns: []string{"schema/**/*.graphql"}, Excludes: []string{"schema/drafts/**"}},
+		{Patterns: []string{"remote.json"}, Introspection: true},
+	}, conf.Sources)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+goModule: github.com/molon/genx/example
+extensions:
+  - name: relayext
+`), 0o644))
+	_, err = generator.LoadConfig(path)
+	require.ErrorContains(t, err, "PrototypeRelPattern")
+}
+
+func TestLoadConfigTargets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "genx.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+prototypeRelPattern: prototype.graphql
+goModule: github.com/molon/genx/example
+extensions:
+  - name: relayext
+targets:
+  - name: users
+    outputDir: services/users
+  - name: billing
+    outputDir: services/billing
+    sources:
+      - patterns: ["schema/**/*.graphql"]
+    goModule: github.com/molon/genx/billing
+    extensions:
+      - name: relayext
+      - name: cleanup
+`), 0o644))
+
+	conf, err := generator.LoadConfig(path)
+	require.NoError(t, err)
+	confs := conf.TargetConfigs()
+	require.Len(t, confs, 2)
+
+	assert.Equal(t, "users", confs[0].Target)
+	assert.Equal(t, filepath.Join(dir, "services", "users"), confs[0].OutputDir)
+	assert.Equal(t, "prototype.graphql", confs[0].PrototypeRelPattern)
+	assert.Equal(t, "github.com/molon/genx/example", confs[0].GoModule)
+	assert.Len(t, confs[0].Extensions, 1)
+	assert.Empty(t, confs[0].Targets)
+
+	assert.Equal(t, "billing", confs[1].Target)
+	assert.Empty(t, confs[1].PrototypeRelPattern)
+	assert.Len(t, confs[1].Sources, 1)
+	assert.Equal(t, "github.com/molon/genx/billing", confs[1].GoModule)
+	assert.Len(t, confs[1].Extensions, 2)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+goModule: github.com/molon/genx/example
+targets:
+  - name: users
+    outputDir: services/users
+    prototypeRelPattern: prototype.graphql
+`), 0o644))
+	_, err = generator.LoadConfig(path)
+	require.ErrorContains(t, err, "target users has no extensions")
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "genx.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+prototypeRelPattern: prototype.graphql
+goModule: github.com/molon/genx/example
+`), 0o644))
+
+	_, err := generator.LoadConfig(path)
+	require.ErrorContains(t, err, "validation failed")
+}
+
+func TestNewExtensions(t *testing.T) {
+	_, err := generator.NewExtensions([]*generator.ExtensionConfig{{Name: "unknown"}})
+	require.ErrorContains(t, err, `unknown extension "unknown"`)
+
+	_, err = generator.NewExtensions([]*generator.ExtensionConfig{{Name: "cleanup", Options: map[string]any{"foo": "bar"}}})
+	require.ErrorContains(t, err, "failed to create extension cleanup")
+
+	_, err = generator.NewExtensions([]*generator.ExtensionConfig{{Name: "relayext", Options: map[string]any{
+		"layout":         map[string]any{"modelDir": "internal/graph/model", "nodeResolverFile": "{{ .Name | snakeCase }}.genx.go"},
+		"globalIdFormat": "prefixed",
+	}}})
+	require.NoError(t, err)
+
+	_, err = generator.NewExtensions([]*generator.ExtensionConfig{{Name: "relayext", Options: map[string]any{
+		"layout": map[string]any{"modelDirectory": "internal/graph/model"},
+	}}})
+	require.ErrorContains(t, err, "failed to create extension relayext")
+
+	_, err = generator.NewExtensions([]*generator.ExtensionConfig{{Name: "cleanup", DependsOn: []string{"relayext"}}})
+	require.ErrorContains(t, err, "extension cleanup sets options of external extensions without a command")
+
+	extensions, err := generator.NewExtensions([]*generator.ExtensionConfig{
+		{Name: "relayext"},
+		{Name: "mocks", Command: []string{"node", "tools/mocks.js"}, DependsOn: []string{"relayext"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, extensions, 2)
+	assert.Equal(t, "mocks", extensions[1].Name())
+	assert.Equal(t, []string{"relayext"}, extensions[1].(genx.WithDependencies).DependsOn())
+}
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/molon/genx"
+	"github.com/molon/genx/extension/execext"
+	"github.com/molon/genx/pkg/configx"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// Factory creates an extension from the options declared in the config file.
+type Factory func(options map[string]any) (genx.Extension, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes an extension available to the config file under the given name.
+// It panics if the name is already registered.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("generator: register factory is nil for " + name)
+	}
+	if _, exists := registry[name]; exists {
+		panic("generator: register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// Registered returns the sorted names of all registered extensions.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registeredNames()
+}
+
+func registeredNames() []string {
+	names := lo.Keys(registry)
+	sort.Strings(names)
+	return names
+}
+
+func NewExtensions(confs []*ExtensionConfig) ([]genx.Extension, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	extensions := make([]genx.Extension, 0, len(confs))
+	for _, conf := range confs {
+		if len(conf.Command) > 0 {
+			extensions = append(extensions, newExecExtension(conf))
+			continue
+		}
+		if conf.Dir != "" || len(conf.Env) > 0 || len(conf.Phases) > 0 ||
+			len(conf.DependsOn) > 0 || len(conf.RunsBefore) > 0 || len(conf.RunsAfter) > 0 {
+			return nil, errors.Errorf("extension %s sets options of external extensions without a command", conf.Name)
+		}
+		factory, ok := registry[conf.Name]
+		if !ok {
+			return nil, errors.Errorf("unknown extension %q, available: %v", conf.Name, registeredNames())
+		}
+		ext, err := factory(conf.Options)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create extension %s", conf.Name)
+		}
+		extensions = append(extensions, ext)
+	}
+	return extensions, nil
+}
+
+func newExecExtension(conf *ExtensionConfig) genx.Extension {
+	ext := execext.New(conf.Name, conf.Command, conf.Options)
+	ext.Dir = conf.Dir
+	ext.Env = conf.Env
+	ext.Phases = lo.Map(conf.Phases, func(phase string, _ int) execext.Phase { return execext.Phase(phase) })
+	ext.Dependencies = conf.DependsOn
+	ext.Before = conf.RunsBefore
+	ext.After = conf.RunsAfter
+	return ext
+}
+
+// DecodeOptions decodes the extension options into target and rejects unknown keys.
+func DecodeOptions(options map[string]any, target any) error {
+	dc := &mapstructure.DecoderConfig{
+		Result:      target,
+		ErrorUnused: true,
+	}
+	configx.DecoderConfigOption(dc)
+	decoder, err := mapstructure.NewDecoder(dc)
+	if err != nil {
+		return errors.Wrap(err, "failed to create options decoder")
+	}
+	if err := decoder.Decode(options); err != nil {
+		return errors.Wrap(err, "failed to decode options")
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+package generator
+
+import (
+	"context"
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/molon/genx"
+	"github.com/molon/genx/pkg/gqlx"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+const (
+	defaultWatchDebounce = 300 * time.Millisecond
+
+	// goHookExtension is the extension operating on the user go files next to the generated ones
+	goHookExtension = "gosurgery"
+	// gqlgenExtension is skipped when only go hook files changed, since the schema is unaffected
+	gqlgenExtension = "gqlgenext"
+)
+
+// WatchRun describes a run of the pipeline triggered by Watch.
+type WatchRun struct {
+	// Target is the name of the regenerated target, if any
+	Target string
+	// Changed are the changed files relative to the output dir, empty for the initial run
+	Changed []string
+	// GoHooksOnly reports whether only go hook files changed, the gqlgen step is skipped then
+	GoHooksOnly bool
+	Duration    time.Duration
+	Err         error
+}
+
+// Watch generates once and then again whenever the prototype files or the user go files in the
+// directories operated on by gosurgery change, until ctx is done. A failed run does not stop watching,
+// its error is passed to onRun like the results of all other runs.
+// Every target is watched and regenerated on its own, the calls of onRun are serialized.
+func Watch(ctx context.Context, conf *Config, onRun func(run *WatchRun), options ...genx.Option) error {
+	if len(conf.Targets) == 0 {
+		return watchTarget(ctx, conf, onRun, nil, options...)
+	}
+
+	var mu sync.Mutex
+	serialized := func(run *WatchRun) {
+		mu.Lock()
+		defer mu.Unlock()
+		onRun(run)
+	}
+	// only the initial runs share a cache, the later ones regenerate single targets
+	cache := genx.NewFormatCache()
+
+	// a target failing to watch stops the others
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	confs := conf.TargetConfigs()
+	errs := make([]error, len(confs))
+	var wg sync.WaitGroup
+	for i, c := range confs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := watchTarget(ctx, c, serialized, cache, options...); err != nil {
+				errs[i] = errors.Wrapf(err, "target %s", c.Target)
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return stderrors.Join(errs...)
+}
+
+// watchTarget watches a single target, the format cache is only used by its initial run,
+// so that a long session does not keep every version of the generated files.
+func watchTarget(ctx context.Context, conf *Config, onRun func(run *WatchRun), cache *genx.FormatCache, options ...genx.Option) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create file watcher")
+	}
+	defer watcher.Close()
+
+	w := &watch{
+		conf:    conf,
+		sources: (&genx.Config{PrototypeRelPattern: conf.PrototypeRelPattern, Sources: schemaSources(conf)}).SchemaSources(),
+		watcher: watcher,
+		dirs:    make(map[string]bool),
+		goDirs:  make(map[string]bool),
+		hashes:  make(map[string]string),
+	}
+	debounce := conf.WatchDebounce
+	if debounce <= 0 {
+		debounce = defaultWatchDebounce
+	}
+
+	run := func(changed []string) error {
+		goHooksOnly := len(changed) > 0 && lo.EveryBy(changed, func(name string) bool {
+			return !w.isPrototype(name)
+		})
+		runConf := conf
+		if goHooksOnly {
+			copied := *conf
+			copied.Extensions = lo.Reject(conf.Extensions, func(ext *ExtensionConfig, _ int) bool {
+				return ext.Name == gqlgenExtension
+			})
+			runConf = &copied
+		}
+
+		runOptions := options
+		if cache != nil {
+			runOptions = append([]genx.Option{genx.ShareFormatCache(cache)}, options...)
+			cache = nil
+		}
+
+		start := time.Now()
+		genErr := Generate(ctx, runConf, runOptions...)
+		onRun(&WatchRun{
+			Target:      conf.Target,
+			Changed:     changed,
+			GoHooksOnly: goHooksOnly,
+			Duration:    time.Since(start),
+			Err:         genErr,
+		})
+		// the outputs of the run must not trigger another one
+		return w.refresh()
+	}
+
+	if err := run(nil); err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return errors.Wrap(err, "failed to watch files")
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			name, changed := w.changed(event.Name)
+			if !changed {
+				continue
+			}
+			pending[name] = true
+			timer.Reset(debounce)
+		case <-timer.C:
+			changed := lo.Keys(pending)
+			sort.Strings(changed)
+			clear(pending)
+			if err := run(changed); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type watch struct {
+	conf    *Config
+	sources []*genx.SchemaSource
+	watcher *fsnotify.Watcher
+	// dirs are the watched directories relative to the output dir
+	dirs map[string]bool
+	// goDirs are the directories containing generated go files
+	goDirs map[string]bool
+	// hashes of the watched files relative to the output dir, used to ignore events that do not change anything
+	hashes map[string]string
+}
+
+func (w *watch) isPrototype(name string) bool {
+	return lo.ContainsBy(w.sources, func(src *genx.SchemaSource) bool {
+		return src.Match(name)
+	})
+}
+
+func (w *watch) isGoHook(name string) bool {
+	return w.goDirs[path.Dir(name)] &&
+		strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, ".genx.go")
+}
+
+func (w *watch) goHooks() bool {
+	return lo.ContainsBy(w.conf.Extensions, func(ext *ExtensionConfig) bool {
+		return ext.Name == goHookExtension
+	})
+}
+
+// changed reports whether the content of the watched file at the absolute path differs from the last one seen.
+func (w *watch) changed(absPath string) (string, bool) {
+	rel, err := filepath.Rel(w.conf.OutputDir, absPath)
+	if err != nil {
+		return "", false
+	}
+	name := filepath.ToSlash(rel)
+	if !w.isPrototype(name) && !w.isGoHook(name) {
+		return "", false
+	}
+	hash := w.hash(name)
+	if prev, ok := w.hashes[name]; ok && prev == hash {
+		return "", false
+	}
+	w.hashes[name] = hash
+	return name, true
+}
+
+func (w *watch) hash(name string) string {
+	data, err := os.ReadFile(filepath.Join(w.conf.OutputDir, filepath.FromSlash(name)))
+	if err != nil {
+		// removed
+		return ""
+	}
+	return genx.Hash(data)
+}
+
+// refresh watches the directories of the prototype files and those containing generated go files,
+// and records the hashes of the watched files.
+func (w *watch) refresh() error {
+	fsys := os.DirFS(w.conf.OutputDir)
+
+	dirs := make(map[string]bool)
+	for _, src := range w.sources {
+		for _, pattern := range src.Patterns {
+			if err := addPatternDirs(fsys, pattern, dirs); err != nil {
+				return err
+			}
+		}
+		prototypes, err := src.Glob(fsys)
+		if err != nil {
+			return err
+		}
+		for _, name := range prototypes {
+			dirs[path.Dir(name)] = true
+		}
+	}
+	if w.goHooks() {
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if name != "." && strings.HasPrefix(d.Name(), ".") {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(name, ".genx.go") {
+				dirs[path.Dir(name)] = true
+				w.goDirs[path.Dir(name)] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to find generated go files")
+		}
+	}
+
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(filepath.Join(w.conf.OutputDir, filepath.FromSlash(dir))); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to watch %s", dir)
+		}
+		w.dirs[dir] = true
+	}
+
+	for dir := range w.dirs {
+		entries, err := fs.ReadDir(fsys, dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := path.Join(dir, e.Name())
+			if e.Type().IsRegular() && (w.isPrototype(name) || w.isGoHook(name)) {
+				w.hashes[name] = w.hash(name)
+			}
+		}
+	}
+	return nil
+}
+
+// addPatternDirs adds the directory of the pattern before its first wildcard,
+// and all directories below it if the pattern recurses with **.
+func addPatternDirs(fsys fs.FS, pattern string, dirs map[string]bool) error {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	root := gqlx.PatternDir(pattern)
+	dirs[root] = true
+	if !strings.Contains(pattern, "**") {
+		return nil
+	}
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if name != root && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		dirs[name] = true
+		return nil
+	})
+	return errors.Wrapf(err, "failed to find directories matching %s", pattern)
+}
@@ -0,0 +1,76 @@
+package generator_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/molon/genx/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	prototype := filepath.Join(dir, "prototype.graphql")
+	require.NoError(t, os.WriteFile(prototype, []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+
+	conf := &generator.Config{
+		OutputDir:           dir,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/molon/genx/example",
+		Extensions: []*generator.ExtensionConfig{
+			{Name: "relayext"},
+			{Name: "gosurgery"},
+		},
+		WatchDebounce: 20 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan *generator.WatchRun, 8)
+	done := make(chan error, 1)
+	go func() {
+		done <- generator.Watch(ctx, conf, func(run *generator.WatchRun) {
+			runs <- run
+		})
+	}()
+	next := func() *generator.WatchRun {
+		select {
+		case run := <-runs:
+			return run
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a run")
+			return nil
+		}
+	}
+
+	run := next()
+	require.NoError(t, run.Err)
+	assert.Empty(t, run.Changed)
+
+	require.NoError(t, os.WriteFile(prototype, []byte("type User @node {\n  name: String!\n  age: Int!\n}\n"), 0o644))
+	run = next()
+	require.NoError(t, run.Err)
+	assert.Equal(t, []string{"prototype.graphql"}, run.Changed)
+	assert.False(t, run.GoHooksOnly)
+
+	hook := filepath.Join(dir, "server", "resolver", "user_hooks.go")
+	require.NoError(t, os.WriteFile(hook, []byte("package resolver\n\nfunc (r *UserResolver) hookCreate() {}\n"), 0o644))
+	run = next()
+	require.NoError(t, run.Err)
+	assert.Equal(t, []string{"server/resolver/user_hooks.go"}, run.Changed)
+	assert.True(t, run.GoHooksOnly)
+
+	// rewriting the same content does not trigger a run
+	require.NoError(t, os.WriteFile(hook, []byte("package resolver\n\nfunc (r *UserResolver) hookCreate() {}\n"), 0o644))
+	select {
+	case run := <-runs:
+		t.Fatalf("unexpected run for %v", run.Changed)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
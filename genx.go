@@ -3,51 +3,146 @@ package genx
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
-	"github.com/molon/genx/pkg/gqlx"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"github.com/vektah/gqlparser/v2"
 )
 
 func Generate(ctx context.Context, config *Config, options ...Option) error {
+	// the run completes a copy, so that the config of the caller can be reused
+	conf := *config
+	conf.Extensions = slices.Clone(config.Extensions)
+	config = &conf
 	if err := applyOptions(config, options...); err != nil {
 		return err
 	}
 	if err := validateConfig(config); err != nil {
 		return err
 	}
+	extensions, err := sortExtensions(config.Extensions)
+	if err != nil {
+		return err
+	}
+	config.Extensions = extensions
+	config.FS = config.outputFS()
 	runtime := &Runtime{
-		Config:  config,
-		Schema:  nil,
-		Results: make(map[string]*Result),
+		Config:       config,
+		FS:           config.FS,
+		Schema:       nil,
+		Results:      make(map[string]*Result),
+		Logger:       config.Logger,
+		sourceHashes: make(map[string]string),
+		unchanged:    make(map[string]bool),
+		contributors: make(map[string][]string),
+		stagedBy:     make(map[string]string),
+	}
+	if runtime.Logger == nil {
+		runtime.Logger = discardLogger
+	}
+	if config.Target != "" {
+		runtime.Logger = runtime.Logger.With("target", config.Target)
+	}
+	if config.OnEvent != nil {
+		runtime.Subscribe(config.OnEvent)
+	}
+	counts := make(map[EventKind]int)
+	runtime.Subscribe(func(e *Event) {
+		counts[e.Kind]++
+	})
+	// every write is staged so that a failing phase leaves the output dir untouched
+	staged := config.Changeset
+	if !config.DryRun {
+		staged = NewChangeset()
+	}
+	runtime.FS = newStagingFS(runtime, staged.Overlay(config.FS))
+	ctx = withFormatters(ctx, runtime.formatters(ctx))
+	if config.FormatCache != nil {
+		ctx = withFormatCache(ctx, config.FormatCache)
+	}
+	start := time.Now()
+	if err := generate(ctx, runtime); err != nil {
+		return runtime.fail(err)
+	}
+	if !config.DryRun {
+		if err := runtime.runPhase(PhaseCommit, func() error {
+			return commit(runtime, staged)
+		}); err != nil {
+			return runtime.fail(errors.Wrap(err, "failed to commit the generated files"))
+		}
+	}
+	if !config.DryRun {
+		runtime.Logger.Info("generated",
+			"written", counts[EventFileWritten],
+			"unchanged", counts[EventFileUnchanged],
+			"deleted", counts[EventFileDeleted],
+			"duration", time.Since(start),
+		)
+	}
+	return nil
+}
+
+func generate(ctx context.Context, runtime *Runtime) error {
+	incremental := runtime.Incremental && !runtime.DryRun
+	if incremental {
+		upToDate, err := checkManifest(ctx, runtime)
+		if err != nil {
+			return err
+		}
+		if upToDate {
+			return nil
+		}
 	}
-	if err := beforeGenerate(ctx, runtime); err != nil {
+	if err := runtime.runPhase(PhaseBeforeGenerate, func() error {
+		return beforeGenerate(ctx, runtime)
+	}); err != nil {
 		return err
 	}
 	if runtime.Schema == nil {
-		if err := loadSchema(config, runtime); err != nil {
+		if err := runtime.runPhase(PhaseLoadSchema, func() error {
+			return loadSchema(runtime)
+		}); err != nil {
 			return err
 		}
 	}
-	if err := generateFiles(ctx, runtime); err != nil {
+	if err := runtime.runPhase(PhaseGenerate, func() error {
+		if err := generateFiles(ctx, runtime); err != nil {
+			return err
+		}
+		return checkDuplicateFiles(runtime)
+	}); err != nil {
 		return err
 	}
-	if err := checkDuplicateFiles(runtime); err != nil {
-		return err
+	if incremental {
+		reuseOutputs(runtime)
 	}
-	if err := formatFiles(ctx, runtime.Results); err != nil {
+	if err := runtime.runPhase(PhaseFormat, func() error {
+		return formatFiles(ctx, runtime)
+	}); err != nil {
 		return err
 	}
-	if err := writeFiles(config.OutputDir, runtime.Results); err != nil {
+	if err := runtime.runPhase(PhaseWrite, func() error {
+		return writeFiles(ctx, runtime)
+	}); err != nil {
 		return err
 	}
-	if err := afterGenerate(ctx, runtime); err != nil {
+	if err := runtime.runPhase(PhaseAfterGenerate, func() error {
+		return afterGenerate(ctx, runtime)
+	}); err != nil {
 		return err
 	}
+	if incremental {
+		if err := saveManifest(ctx, runtime); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -61,15 +156,23 @@ func applyOptions(config *Config, options ...Option) error {
 }
 
 func validateConfig(config *Config) error {
-	if config.OutputDir == "" {
-		return errors.New("output dir is required")
+	if config.OutputDir == "" && config.FS == nil {
+		return errors.New("output dir or fs is required")
 	}
-	if config.PrototypeRelPattern == "" {
-		return errors.New("prototype rel pattern is required")
+	if config.PrototypeRelPattern == "" && len(config.Sources) == 0 {
+		return errors.New("prototype rel pattern or sources are required")
+	}
+	for _, src := range config.Sources {
+		if len(src.Patterns) == 0 {
+			return errors.New("schema source without patterns")
+		}
 	}
 	if len(config.Extensions) == 0 {
 		return errors.New("no extensions")
 	}
+	if config.DryRun && config.Changeset == nil {
+		return errors.New("dry run without a changeset to record the planned changes into")
+	}
 	duplicatedExtensions := lo.Map(lo.FindDuplicatesBy(config.Extensions, func(ext Extension) string {
 		return ext.Name()
 	}), func(ext Extension, _ int) string { return ext.Name() })
@@ -81,25 +184,28 @@ func validateConfig(config *Config) error {
 
 func beforeGenerate(ctx context.Context, runtime *Runtime) error {
 	for _, ext := range runtime.Config.Extensions {
-		if err := ext.BeforeGenerate(ctx, runtime); err != nil {
+		if err := callExtension(runtime, ext, func() error {
+			return ext.BeforeGenerate(ctx, runtime)
+		}); err != nil {
 			return err
 		}
 	}
-	return nil
+	return runtime.checkDiagnostics()
 }
 
 func afterGenerate(ctx context.Context, runtime *Runtime) error {
 	for _, ext := range runtime.Config.Extensions {
-		if err := ext.AfterGenerate(ctx, runtime); err != nil {
+		if err := callExtension(runtime, ext, func() error {
+			return ext.AfterGenerate(ctx, runtime)
+		}); err != nil {
 			return err
 		}
 	}
-	return nil
+	return runtime.checkDiagnostics()
 }
 
-func loadSchema(config *Config, runtime *Runtime) error {
-	prototypePattern := filepath.Join(config.OutputDir, config.PrototypeRelPattern)
-	sources, err := gqlx.LoadSources(prototypePattern)
+func loadSchema(runtime *Runtime) error {
+	sources, err := runtime.LoadSources()
 	if err != nil {
 		return err
 	}
@@ -113,13 +219,22 @@ func loadSchema(config *Config, runtime *Runtime) error {
 
 func generateFiles(ctx context.Context, runtime *Runtime) error {
 	for _, ext := range runtime.Config.Extensions {
-		result, err := ext.Generate(ctx, runtime)
-		if err != nil {
+		var result *Result
+		if err := callExtension(runtime, ext, func() (err error) {
+			result, err = ext.Generate(ctx, runtime)
+			return err
+		}); err != nil {
+			return err
+		}
+		if result == nil {
+			result = &Result{}
+		}
+		if err := mergeFragments(runtime, ext, result); err != nil {
 			return err
 		}
 		runtime.Results[ext.Name()] = result
 	}
-	return nil
+	return runtime.checkDiagnostics()
 }
 
 func checkDuplicateFiles(runtime *Runtime) error {
@@ -136,34 +251,109 @@ func checkDuplicateFiles(runtime *Runtime) error {
 		}
 	}
 	if len(duplicatedFiles) > 0 {
-		return errors.Errorf("duplicate files detected:\n%s", strings.Join(duplicatedFiles, "\n"))
+		sort.Strings(duplicatedFiles)
+		return errors.Errorf("duplicate files detected, set File.Merge on all of them to combine them:\n%s", strings.Join(duplicatedFiles, "\n"))
 	}
 	return nil
 }
 
-func formatFiles(ctx context.Context, results map[string]*Result) error {
-	for _, result := range results {
-		for _, file := range result.Files {
-			if err := file.Format(ctx); err != nil {
-				return err
-			}
+func formatFiles(ctx context.Context, runtime *Runtime) error {
+	return forEachFile(ctx, runtime, func(ctx context.Context, file *File) error {
+		if runtime.unchanged[FSName(file.RelPath)] {
+			return nil
 		}
-	}
-	return nil
+		start := time.Now()
+		if err := file.Format(ctx); err != nil {
+			return err
+		}
+		runtime.Emit(&Event{Kind: EventFileFormatted, Phase: PhaseFormat, File: FSName(file.RelPath), Duration: time.Since(start)})
+		return nil
+	})
 }
 
-func writeFiles(outputDir string, results map[string]*Result) error {
-	for _, result := range results {
-		for _, file := range result.Files {
-			path := filepath.Join(outputDir, file.RelPath)
-			dir := filepath.Dir(path)
-			if err := os.MkdirAll(dir, os.ModePerm); err != nil {
-				return errors.Wrapf(err, "failed to create directory %s", dir)
-			}
-			if err := os.WriteFile(path, []byte(file.Content), os.ModePerm); err != nil {
-				return errors.Wrapf(err, "failed to write file %s", path)
-			}
+func writeFiles(ctx context.Context, runtime *Runtime) error {
+	return forEachFile(ctx, runtime, func(_ context.Context, file *File) error {
+		name := FSName(file.RelPath)
+		if runtime.unchanged[name] {
+			runtime.Emit(&Event{Kind: EventFileUnchanged, Phase: PhaseWrite, File: name, Extension: runtime.contributorsOf(name)})
+			return nil
 		}
+		dir := path.Dir(name)
+		if err := runtime.FS.MkdirAll(dir, os.ModePerm); err != nil {
+			return errors.Wrapf(err, "failed to create directory %s", dir)
+		}
+		if err := runtime.FS.WriteFile(name, []byte(file.Content), 0o644); err != nil {
+			return errors.Wrapf(err, "failed to write file %s", name)
+		}
+		return nil
+	})
+}
+
+// stagingFS attributes the staged writes and removals to the extension being called.
+type stagingFS struct {
+	FS
+	runtime *Runtime
+}
+
+// stagingDirFS is a stagingFS over an output dir on the OS file system, which it reports as its Dir
+// although the staged content is not there yet.
+type stagingDirFS struct {
+	*stagingFS
+	dir string
+}
+
+func (f *stagingDirFS) Dir() string {
+	return f.dir
+}
+
+func newStagingFS(runtime *Runtime, overlay FS) FS {
+	f := &stagingFS{FS: overlay, runtime: runtime}
+	if dirFS, ok := runtime.Config.FS.(DirFS); ok {
+		return &stagingDirFS{stagingFS: f, dir: dirFS.Dir()}
 	}
-	return nil
+	return f
+}
+
+func (f *stagingFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	f.stage(name)
+	return f.FS.WriteFile(name, data, perm)
+}
+
+func (f *stagingFS) Remove(name string) error {
+	f.stage(name)
+	return f.FS.Remove(name)
+}
+
+func (f *stagingFS) stage(name string) {
+	f.runtime.mu.Lock()
+	defer f.runtime.mu.Unlock()
+	if f.runtime.extension != "" {
+		f.runtime.stagedBy[name] = f.runtime.extension
+	}
+}
+
+// contributorsOf returns the extensions that generated the file.
+func (r *Runtime) contributorsOf(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ext, ok := r.stagedBy[name]; ok {
+		return ext
+	}
+	return strings.Join(r.contributors[name], ", ")
+}
+
+// commit applies the staged changes to the output dir and emits the file events.
+func commit(runtime *Runtime, staged *Changeset) error {
+	return staged.commit(runtime.Config.FS, func(change *Change, modified bool) {
+		event := &Event{Phase: PhaseCommit, File: change.RelPath, Extension: runtime.contributorsOf(change.RelPath)}
+		switch {
+		case change.Op == ChangeOpDelete:
+			event.Kind = EventFileDeleted
+		case modified:
+			event.Kind = EventFileWritten
+		default:
+			event.Kind = EventFileUnchanged
+		}
+		runtime.Emit(event)
+	})
 }
@@ -2,21 +2,356 @@ package genx_test
 
 import (
 	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/molon/genx"
+	"github.com/molon/genx/extension/cleanup"
+	"github.com/molon/genx/extension/gosurgery"
+	"github.com/molon/genx/extension/gqlgenext"
 	"github.com/molon/genx/extension/relayext"
+	"github.com/molon/genx/genxtest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// starterFiles are the inputs of the starter needed to run gqlgen and compile the output.
+func starterFiles(t *testing.T) map[string]string {
+	files := map[string]string{
+		"go.mod": genxtest.ReadGoMod(t, "starter/boilerplate/go.mod"),
+	}
+	for _, name := range []string{"go.sum", "gqlgen.yml", "server/gqlresolver.go"} {
+		content, err := os.ReadFile(filepath.Join("starter/boilerplate", filepath.FromSlash(name)))
+		require.NoError(t, err)
+		files[name] = string(content)
+	}
+	return files
+}
+
 func TestGenerate(t *testing.T) {
+	genxtest.SkipIfToolchainUnsupported(t)
+
+	files := starterFiles(t)
+	files["server/resolver/user_resolver.go"] = `package resolver
+
+import (
+	"context"
+
+	"github.com/molon/genx/starter/boilerplate/server/model"
+)
+
+func (c *UserResolver) hookCreate(next func(ctx context.Context, user *model.User) error) func(ctx context.Context, user *model.User) error {
+	return next
+}
+`
+	genxtest.Run(t, &genxtest.Case{
+		Prototype: `type Company @node {
+  name: String!
+  description: String
+  employees: [User!]!
+}
+
+type User @node {
+  name: String!
+  age: Int!
+  company: Company!
+}
+`,
+		Files:      files,
+		GoModule:   "github.com/molon/genx/starter/boilerplate",
+		Extensions: []genx.Extension{relayext.New(), gosurgery.New(), gqlgenext.New()},
+		// the outputs of gqlgen itself
+		Ignore:  []string{"server/exec/*.generated.go", "server/model/models.gqlgen.go"},
+		Compile: true,
+	})
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prototype.graphql"), []byte(`
+type User @node {
+  name: String!
+}
+`), 0o644))
+
+	newConfig := func() *genx.Config {
+		return &genx.Config{
+			OutputDir:           dir,
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/molon/genx/__testdata",
+			Extensions:          []genx.Extension{relayext.New(), cleanup.New()},
+		}
+	}
+
+	report, err := genx.Check(context.Background(), newConfig())
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Contains(t, report.Missing, "schema/schema.genx.graphql")
+	assert.Empty(t, report.Stale)
+	assert.Empty(t, report.Extra)
+
+	require.NoError(t, genx.Generate(context.Background(), newConfig()))
+	report, err = genx.Check(context.Background(), newConfig())
+	require.NoError(t, err)
+	assert.True(t, report.OK(), report.String())
+
+	extra := filepath.Join(dir, "server", "resolver", "post_resolver.genx.go")
+	require.NoError(t, os.WriteFile(extra, []byte("package resolver\n"), 0o644))
+	stale := filepath.Join(dir, "server", "model", "models.genx.go")
+	require.NoError(t, os.WriteFile(stale, []byte("package model\n"), 0o644))
+	report, err = genx.Check(context.Background(), newConfig())
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("server", "model", "models.genx.go")}, report.Stale)
+	assert.Equal(t, []string{filepath.Join("server", "resolver", "post_resolver.genx.go")}, report.Extra)
+	assert.Empty(t, report.Missing)
+}
+
+func TestGenerateMemFS(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte(`
+type User @node {
+  name: String!
+}
+`), 0o644))
+	require.NoError(t, fsys.WriteFile("server/resolver/user_resolver.go", []byte(`package resolver
+
+func (c *UserResolver) hookCreate() {}
+`), 0o644))
+	require.NoError(t, fsys.WriteFile("server/resolver/stale_resolver.genx.go", []byte("package resolver\n"), 0o644))
+
 	err := genx.Generate(context.Background(), &genx.Config{
-		OutputDir:           "./__testdata",
+		FS:                  fsys,
 		PrototypeRelPattern: "prototype.graphql",
 		GoModule:            "github.com/molon/genx/__testdata",
 	}, genx.Extensions(
 		relayext.New(),
+		gosurgery.New(),
+		cleanup.New(),
 	))
 	require.NoError(t, err)
-	// TODO:
+	assert.Equal(t, []string{
+		"prototype.graphql",
+		"schema/schema.genx.graphql",
+		"server/model/models.genx.go",
+		"server/resolver/resolver.genx.go",
+		"server/resolver/user_resolver.genx.go",
+		"server/resolver/user_resolver.go",
+	}, fsys.Names())
+}
+
+func TestGenerateIncremental(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte(`
+type User @node {
+  name: String!
+}
+`), 0o644))
+
+	generate := func() {
+		err := genx.Generate(context.Background(), &genx.Config{
+			FS:                  fsys,
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/molon/genx/__testdata",
+			Incremental:         true,
+		}, genx.Extensions(
+			relayext.New(),
+			gosurgery.New(),
+			cleanup.New(),
+		))
+		require.NoError(t, err)
+	}
+	modTimes := func() map[string]time.Time {
+		m := map[string]time.Time{}
+		for _, name := range fsys.Names() {
+			info, err := fs.Stat(fsys, name)
+			require.NoError(t, err)
+			m[name] = info.ModTime()
+		}
+		return m
+	}
+
+	generate()
+	require.Contains(t, fsys.Names(), genx.ManifestRelPath)
+	first := modTimes()
+
+	// nothing changed, the whole run is skipped
+	generate()
+	assert.Equal(t, first, modTimes())
+
+	// only the affected files are rewritten
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte(`
+type User @node {
+  name: String!
+}
+
+type Post @node {
+  title: String!
+}
+`), 0o644))
+	generate()
+	second := modTimes()
+	assert.Equal(t, first["server/resolver/user_resolver.genx.go"], second["server/resolver/user_resolver.genx.go"])
+	assert.NotEqual(t, first["server/model/models.genx.go"], second["server/model/models.genx.go"])
+	assert.Contains(t, second, "server/resolver/post_resolver.genx.go")
+
+	// a touched output is regenerated
+	require.NoError(t, fsys.WriteFile("server/resolver/user_resolver.genx.go", []byte("package resolver\n"), 0o644))
+	generate()
+	content, err := fs.ReadFile(fsys, "server/resolver/user_resolver.genx.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "type UserResolver struct")
+}
+
+type brokenExtension struct {
+	genx.DefaultExtension
+}
+
+func (e *brokenExtension) Name() string {
+	return "broken"
+}
+
+func (e *brokenExtension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result, error) {
+	return &genx.Result{Files: []*genx.File{
+		{RelPath: "a/broken.genx.go", Content: "package a\nfunc {"},
+		{RelPath: "b/broken.genx.go", Content: "package b\nfunc {"},
+		{RelPath: "c/fine.genx.go", Content: "package c\n"},
+	}}, nil
+}
+
+func TestGenerateAggregatesFormatErrors(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte(`type Query { hello: String }`), 0o644))
+
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		Concurrency:         2,
+	}, genx.Extensions(&brokenExtension{}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to format a/broken.genx.go")
+	assert.Contains(t, err.Error(), "failed to format b/broken.genx.go")
+	assert.Equal(t, []string{"prototype.graphql"}, fsys.Names())
+}
+
+type failingAfterExtension struct {
+	genx.DefaultExtension
+}
+
+func (e *failingAfterExtension) Name() string {
+	return "failing"
+}
+
+func (e *failingAfterExtension) AfterGenerate(ctx context.Context, r *genx.Runtime) error {
+	return errors.New("gqlgen failed")
+}
+
+func TestGenerateRollsBackOnFailure(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+	require.NoError(t, fsys.WriteFile("server/model/models.genx.go", []byte("package model\n"), 0o600))
+	require.NoError(t, fsys.WriteFile("server/model/stale.genx.go", []byte("package model\n"), 0o644))
+
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(relayext.New(), cleanup.New(), &failingAfterExtension{}))
+	require.ErrorContains(t, err, "gqlgen failed")
+
+	// neither the writes nor the removal of cleanup reached the output dir
+	assert.Equal(t, []string{"prototype.graphql", "server/model/models.genx.go", "server/model/stale.genx.go"}, fsys.Names())
+	content, err := fs.ReadFile(fsys, "server/model/models.genx.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package model\n", string(content))
+
+	err = genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(relayext.New(), cleanup.New()))
+	require.NoError(t, err)
+	assert.NotContains(t, fsys.Names(), "server/model/stale.genx.go")
+	info, err := fs.Stat(fsys, "server/model/models.genx.go")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestGenerateRecoversFormatterPanics(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte(`type Query { hello: String }`), 0o644))
+
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		Formatters: map[string]genx.Formatter{
+			".go": genx.FormatterFunc(func(ctx context.Context, text string) (string, error) {
+				panic("boom")
+			}),
+		},
+	}, genx.Extensions(&brokenExtension{}))
+	var ds genx.Diagnostics
+	require.True(t, errors.As(err, &ds), "%+v", err)
+	require.Len(t, ds, 3)
+	for i, name := range []string{"a/broken.genx.go", "b/broken.genx.go", "c/fine.genx.go"} {
+		assert.Equal(t, name, ds[i].File)
+		assert.Equal(t, "formatter of .go panicked: panic: boom", ds[i].Message)
+	}
+	assert.Equal(t, []string{"prototype.graphql"}, fsys.Names())
+}
+
+type dirExtension struct {
+	genx.DefaultExtension
+	dir string
+}
+
+func (e *dirExtension) Name() string {
+	return "dir"
+}
+
+func (e *dirExtension) AfterGenerate(ctx context.Context, r *genx.Runtime) error {
+	if dirFS, ok := r.FS.(genx.DirFS); ok {
+		e.dir = dirFS.Dir()
+	}
+	return nil
+}
+
+func TestGenerateKeepsConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prototype.graphql"), []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+
+	ext := &dirExtension{}
+	config := &genx.Config{
+		OutputDir:           dir,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+		Extensions:          []genx.Extension{ext},
+	}
+	require.NoError(t, genx.Generate(context.Background(), config, genx.Extensions(relayext.New()), genx.DryRun(genx.NewChangeset())))
+	// the staged run still tells where it is going to be committed
+	assert.Equal(t, dir, ext.dir)
+	assert.Nil(t, config.FS)
+	assert.Nil(t, config.Changeset)
+	assert.False(t, config.DryRun)
+	assert.Equal(t, []genx.Extension{ext}, config.Extensions)
+}
+
+func TestGenerateDryRunWithoutChangeset(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.WriteFile("prototype.graphql", []byte("type User @node {\n  name: String!\n}\n"), 0o644))
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+		DryRun:              true,
+	}, genx.Extensions(relayext.New()))
+	assert.ErrorContains(t, err, "dry run without a changeset")
+	assert.ErrorContains(t, genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(relayext.New()), genx.DryRun(nil)), "dry run without a changeset")
 }
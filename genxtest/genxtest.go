@@ -0,0 +1,231 @@
+// Package genxtest runs extensions against in-memory inputs and compares their outputs with golden files.
+// Run the tests with -update to rewrite the golden files.
+package genxtest
+
+import (
+	"context"
+	"flag"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/modfile"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+const (
+	// PrototypeRelPath is where Case.Prototype is written
+	PrototypeRelPath = "prototype.graphql"
+	// DefaultGoModule is used when Case.GoModule is empty
+	DefaultGoModule = "github.com/example/app"
+
+	goldenExt = ".golden"
+)
+
+type Case struct {
+	// Prototype is the content of PrototypeRelPath
+	Prototype string
+	// Files are the files of the output dir before the run by FS name, e.g. the user hooks or a go.mod
+	Files      map[string]string
+	GoModule   string
+	Extensions []genx.Extension
+	// Options are passed to genx.Generate after the extensions
+	Options []genx.Option
+	// GoldenDir holds a NAME.golden file for every file written by the run, defaults to testdata/TEST_NAME
+	GoldenDir string
+	// Ignore are path.Match patterns of outputs without golden files, e.g. the files of gqlgen
+	Ignore []string
+	// Compile builds the Go packages of the output with go build, Files need a go.mod with the requirements
+	// of the generated code unless it only imports the standard library
+	Compile bool
+	// Test runs go test on the packages of the output, e.g. with test files in Files exercising the generated code
+	Test bool
+}
+
+// Run generates the case into a genx.MemFS and compares every file written or changed by the run with its golden file,
+// golden files without output fail the test as well. The resulting file system is returned for further checks.
+func Run(t testing.TB, c *Case) *genx.MemFS {
+	t.Helper()
+
+	goModule := c.GoModule
+	if goModule == "" {
+		goModule = DefaultGoModule
+	}
+	goldenDir := c.GoldenDir
+	if goldenDir == "" {
+		goldenDir = filepath.Join("testdata", filepath.FromSlash(t.Name()))
+	}
+
+	fsys := genx.NewMemFS()
+	write := func(name, content string) {
+		require.NoError(t, fsys.MkdirAll(path.Dir(name), os.ModePerm))
+		require.NoError(t, fsys.WriteFile(name, []byte(content), 0o644))
+	}
+	write(PrototypeRelPath, c.Prototype)
+	for name, content := range c.Files {
+		write(genx.FSName(name), content)
+	}
+
+	options := append([]genx.Option{genx.Extensions(c.Extensions...)}, c.Options...)
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: PrototypeRelPath,
+		GoModule:            goModule,
+	}, options...)
+	require.NoError(t, err)
+
+	outputs := make(map[string]string)
+	for _, name := range fsys.Names() {
+		if name == PrototypeRelPath {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err)
+		if prev, ok := c.Files[name]; ok && prev == string(content) {
+			continue
+		}
+		if c.ignore(name) {
+			continue
+		}
+		outputs[name] = string(content)
+	}
+	compareGoldenDir(t, goldenDir, outputs)
+
+	if c.Compile {
+		Compile(t, fsys, goModule)
+	}
+	if c.Test {
+		Test(t, fsys, goModule)
+	}
+	return fsys
+}
+
+func (c *Case) ignore(name string) bool {
+	for _, pattern := range c.Ignore {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func compareGoldenDir(t testing.TB, goldenDir string, outputs map[string]string) {
+	t.Helper()
+
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		Golden(t, filepath.Join(goldenDir, filepath.FromSlash(name)+goldenExt), outputs[name])
+	}
+
+	err := filepath.WalkDir(goldenDir, func(goldenPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if goldenPath == goldenDir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(goldenPath, goldenExt) {
+			return nil
+		}
+		rel, err := filepath.Rel(goldenDir, strings.TrimSuffix(goldenPath, goldenExt))
+		if err != nil {
+			return err
+		}
+		if _, ok := outputs[filepath.ToSlash(rel)]; ok {
+			return nil
+		}
+		if *update {
+			return os.Remove(goldenPath)
+		}
+		t.Errorf("golden file %s has no output, run with -update to remove it", goldenPath)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// Golden compares actual with the content of goldenPath, which is rewritten with -update.
+func Golden(t testing.TB, goldenPath string, actual string) {
+	t.Helper()
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(goldenPath), os.ModePerm))
+		require.NoError(t, os.WriteFile(goldenPath, []byte(actual), 0o644))
+		return
+	}
+	expected, err := os.ReadFile(goldenPath)
+	if os.IsNotExist(err) {
+		t.Errorf("golden file %s does not exist, run with -update to create it", goldenPath)
+		return
+	}
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), actual, "output differs from golden file %s, run with -update to accept it", goldenPath)
+}
+
+// Compile copies fsys into a temporary directory and builds all its Go packages,
+// a go.mod declaring goModule is added when fsys has none.
+func Compile(t testing.TB, fsys fs.FS, goModule string) {
+	t.Helper()
+
+	if output, err := goCommand(t, fsys, goModule, "build", "./..."); err != nil {
+		t.Errorf("generated packages do not compile: %v\n%s", err, output)
+	}
+}
+
+// Test copies fsys into a temporary directory like Compile and runs the tests of all its Go packages.
+func Test(t testing.TB, fsys fs.FS, goModule string) {
+	t.Helper()
+
+	if output, err := goCommand(t, fsys, goModule, "test", "./..."); err != nil {
+		t.Errorf("tests of the generated packages fail: %v\n%s", err, output)
+	}
+}
+
+func goCommand(t testing.TB, fsys fs.FS, goModule string, args ...string) ([]byte, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, genx.CopyFS(genx.OSFS(dir), fsys, nil))
+	if _, err := fs.Stat(fsys, "go.mod"); err != nil {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+goModule+"\n\ngo 1.23.0\n"), 0o644))
+	}
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+// ReadGoMod reads a go.mod for Case.Files, its relative replace directives are made absolute
+// since the case is generated and compiled in other directories.
+func ReadGoMod(t testing.TB, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	f, err := modfile.Parse(name, data, nil)
+	require.NoError(t, err)
+	dir, err := filepath.Abs(filepath.Dir(name))
+	require.NoError(t, err)
+	for _, r := range slices.Clone(f.Replace) {
+		if r.New.Version != "" || filepath.IsAbs(r.New.Path) {
+			continue
+		}
+		require.NoError(t, f.AddReplace(r.Old.Path, r.Old.Version, filepath.Join(dir, filepath.FromSlash(r.New.Path)), ""))
+	}
+	data, err = f.Format()
+	require.NoError(t, err)
+	return string(data)
+}
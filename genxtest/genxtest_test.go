@@ -0,0 +1,129 @@
+package genxtest_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/genxtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namesExtension struct {
+	genx.DefaultExtension
+}
+
+func (e *namesExtension) Name() string {
+	return "names"
+}
+
+func (e *namesExtension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result, error) {
+	var names []string
+	for name, def := range r.Schema.Types {
+		if !def.BuiltIn {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	sb.WriteString("package names\n\nvar Types = []string{\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "\t%q,\n", name)
+	}
+	sb.WriteString("}\n")
+	return &genx.Result{Files: []*genx.File{
+		{RelPath: "names/names.genx.go", Content: sb.String()},
+		{RelPath: "names/count.genx.go", Content: fmt.Sprintf("package names\n\nconst Count = %d\n", len(names))},
+	}}, nil
+}
+
+func TestRun(t *testing.T) {
+	fsys := genxtest.Run(t, &genxtest.Case{
+		Prototype: "type User {\n  name: String!\n}\n\ntype Query {\n  users: [User!]!\n}\n",
+		Files: map[string]string{
+			// unchanged inputs have no golden file
+			"names/hooks.go": "package names\n\nfunc Has(name string) bool {\n\tfor _, t := range Types {\n\t\tif t == name {\n\t\t\treturn true\n\t\t}\n\t}\n\treturn false\n}\n",
+		},
+		Extensions: []genx.Extension{&namesExtension{}},
+		Compile:    true,
+	})
+	assert.Contains(t, fsys.Names(), "names/hooks.go")
+}
+
+// recorder collects the errors instead of failing the test.
+type recorder struct {
+	*testing.T
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRunMismatch(t *testing.T) {
+	goldenDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(goldenDir, "names"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(goldenDir, "names", "names.genx.go.golden"), []byte("package names\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(goldenDir, "names", "removed.genx.go.golden"), []byte("package names\n"), 0o644))
+
+	r := &recorder{T: t}
+	genxtest.Run(r, &genxtest.Case{
+		Prototype:  "type Query {\n  hello: String\n}\n",
+		Extensions: []genx.Extension{&namesExtension{}},
+		GoldenDir:  goldenDir,
+		Compile:    true,
+		Files:      map[string]string{"names/broken.go": "package names\n\nvar _ = Missing\n"},
+	})
+	require.Len(t, r.errors, 4)
+	assert.Contains(t, r.errors[0], "names/count.genx.go.golden does not exist, run with -update to create it")
+	assert.Contains(t, r.errors[1], "output differs from golden file "+filepath.Join(goldenDir, "names", "names.genx.go.golden"))
+	assert.Contains(t, r.errors[2], "names/removed.genx.go.golden has no output, run with -update to remove it")
+	assert.Contains(t, r.errors[3], "generated packages do not compile")
+	assert.Contains(t, r.errors[3], "undefined: Missing")
+}
+
+func TestRunIgnore(t *testing.T) {
+	r := &recorder{T: t}
+	genxtest.Run(r, &genxtest.Case{
+		Prototype:  "type Query {\n  hello: String\n}\n",
+		Extensions: []genx.Extension{&namesExtension{}},
+		GoldenDir:  t.TempDir(),
+		Ignore:     []string{"names/*.genx.go"},
+	})
+	assert.Empty(t, r.errors)
+}
+
+func TestReadGoMod(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/example/app\n\ngo 1.23.0\n\nreplace github.com/molon/genx => ../genx\n"), 0o644))
+	goMod := genxtest.ReadGoMod(t, filepath.Join(dir, "go.mod"))
+	assert.Contains(t, goMod, "replace github.com/molon/genx => "+filepath.Join(filepath.Dir(dir), "genx")+"\n")
+}
+
+func TestRunTest(t *testing.T) {
+	test := func(fail string) []string {
+		r := &recorder{T: t}
+		genxtest.Run(r, &genxtest.Case{
+			Prototype:  "type User {\n  name: String!\n}\n\ntype Query {\n  users: [User!]!\n}\n",
+			Extensions: []genx.Extension{&namesExtension{}},
+			GoldenDir:  filepath.Join("testdata", "TestRun"),
+			Files: map[string]string{
+				"names/names_test.go": "package names\n\nimport \"testing\"\n\nfunc TestCount(t *testing.T) {\n\tif " + fail + " {\n\t\tt.Fatal(\"unexpected count\")\n\t}\n}\n",
+			},
+			Test: true,
+		})
+		return r.errors
+	}
+
+	assert.Empty(t, test("Count != 2"))
+	errors := test("Count == 2")
+	require.Len(t, errors, 1)
+	assert.Contains(t, errors[0], "tests of the generated packages fail")
+	assert.Contains(t, errors[0], "unexpected count")
+}
@@ -0,0 +1,45 @@
+package genxtest
+
+import (
+	"bufio"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/gcexportdata"
+)
+
+// SkipIfToolchainUnsupported skips cases loading Go packages, e.g. the cases running gqlgen, when
+// golang.org/x/tools is too old to read the export data of the go command in PATH, it would abort the process.
+func SkipIfToolchainUnsupported(t testing.TB) {
+	t.Helper()
+
+	if err := checkToolchain(); err != nil {
+		t.Skip(err)
+	}
+}
+
+var checkToolchain = sync.OnceValue(func() error {
+	out, err := exec.Command("go", "list", "-export", "-f", "{{.Export}}", "time").Output()
+	if err != nil {
+		return errors.Wrap(err, "failed to build the export data of package time")
+	}
+	f, err := os.Open(strings.TrimSpace(string(out)))
+	if err != nil {
+		return errors.Wrap(err, "failed to open the export data of package time")
+	}
+	defer f.Close()
+	r, err := gcexportdata.NewReader(bufio.NewReader(f))
+	if err == nil {
+		_, err = gcexportdata.Read(r, token.NewFileSet(), make(map[string]*types.Package), "time")
+	}
+	if err != nil {
+		return errors.Wrap(err, "golang.org/x/tools cannot read the export data of the go toolchain")
+	}
+	return nil
+})
@@ -0,0 +1,237 @@
+package genx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ManifestRelPath = ".genx/manifest.json"
+	modulePath      = "github.com/molon/genx"
+)
+
+// Version returns the version of the genx module linked into the binary.
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				return dep.Replace.Path + "@" + dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// Fingerprinter is implemented by extensions whose output depends on more than the prototype,
+// a change of the fingerprint invalidates the manifest of an incremental run.
+type Fingerprinter interface {
+	Fingerprint(ctx context.Context, r *Runtime) (string, error)
+}
+
+// ErrNoFingerprint is returned by a Fingerprinter whose inputs cannot be hashed, e.g. funcs, an incremental run
+// is never skipped as a whole then while its unchanged outputs are still neither formatted nor written.
+var ErrNoFingerprint = errors.New("no fingerprint")
+
+type ManifestFile struct {
+	// SourceHash is the hash of the content before formatting
+	SourceHash string `json:"sourceHash"`
+	// OutputHash is the hash of the content written to disk
+	OutputHash string `json:"outputHash"`
+}
+
+type Manifest struct {
+	Version   string                   `json:"version"`
+	InputHash string                   `json:"inputHash"`
+	Files     map[string]*ManifestFile `json:"files"`
+}
+
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashFS hashes the names and contents of the files in fsys accepted by match, hidden directories are skipped.
+func HashFS(fsys fs.FS, match func(name string) bool) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !match(name) {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write(data)
+		h.Write([]byte{0})
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash files")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func loadManifest(fsys fs.FS) *Manifest {
+	data, err := fs.ReadFile(fsys, ManifestRelPath)
+	if err != nil {
+		return nil
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		// a corrupted manifest only disables the incremental optimizations
+		return nil
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]*ManifestFile)
+	}
+	return &m
+}
+
+func writeManifest(fsys FS, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal manifest")
+	}
+	if err := fsys.MkdirAll(path.Dir(ManifestRelPath), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", path.Dir(ManifestRelPath))
+	}
+	if err := fsys.WriteFile(ManifestRelPath, append(data, '\n'), 0o644); err != nil {
+		return errors.Wrapf(err, "failed to write manifest %s", ManifestRelPath)
+	}
+	return nil
+}
+
+// computeInputHash hashes the inputs of the run, it is empty if an extension has no fingerprint.
+func computeInputHash(ctx context.Context, runtime *Runtime) (string, error) {
+	sources, err := runtime.LoadSources()
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Name < sources[j].Name
+	})
+
+	parts := []string{Version(), runtime.GoModule, runtime.PrototypeRelPattern}
+	for _, source := range sources {
+		parts = append(parts, source.Name, Hash([]byte(source.Input)))
+	}
+	for _, ext := range runtime.Config.Extensions {
+		parts = append(parts, ext.Name())
+		if fp, ok := ext.(Fingerprinter); ok {
+			fingerprint, err := fp.Fingerprint(ctx, runtime)
+			if errors.Is(err, ErrNoFingerprint) {
+				return "", nil
+			}
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to fingerprint extension %s", ext.Name())
+			}
+			parts = append(parts, fingerprint)
+		}
+	}
+	return Hash([]byte(strings.Join(parts, "\x00"))), nil
+}
+
+// checkManifest loads the manifest of the previous run and reports whether its outputs are still up to date.
+func checkManifest(ctx context.Context, runtime *Runtime) (bool, error) {
+	runtime.manifest = loadManifest(runtime.FS)
+	if runtime.manifest == nil {
+		return false, nil
+	}
+	inputHash, err := computeInputHash(ctx, runtime)
+	if err != nil {
+		return false, err
+	}
+	return inputHash != "" && inputHash == runtime.manifest.InputHash && outputsIntact(runtime.FS, runtime.manifest), nil
+}
+
+// outputsIntact reports whether every file recorded in the manifest is still on disk untouched.
+func outputsIntact(fsys fs.FS, m *Manifest) bool {
+	for name, f := range m.Files {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil || Hash(data) != f.OutputHash {
+			return false
+		}
+	}
+	return true
+}
+
+// reuseOutputs marks the files whose unformatted content matches the manifest and whose output on disk
+// is untouched as unchanged, they take over the content on disk so neither formatting nor writing is needed.
+func reuseOutputs(runtime *Runtime) {
+	m := runtime.manifest
+	if m != nil && m.Version != Version() {
+		// the formatting may differ between versions
+		m = nil
+	}
+	for _, result := range runtime.Results {
+		for _, file := range result.Files {
+			name := FSName(file.RelPath)
+			sourceHash := Hash([]byte(file.Content))
+			runtime.sourceHashes[name] = sourceHash
+			if m == nil {
+				continue
+			}
+			prev, ok := m.Files[name]
+			if !ok || prev.SourceHash != sourceHash {
+				continue
+			}
+			data, err := fs.ReadFile(runtime.FS, name)
+			if err != nil || Hash(data) != prev.OutputHash {
+				continue
+			}
+			file.Content = string(data)
+			runtime.unchanged[name] = true
+		}
+	}
+}
+
+func saveManifest(ctx context.Context, runtime *Runtime) error {
+	inputHash, err := computeInputHash(ctx, runtime)
+	if err != nil {
+		return err
+	}
+	m := &Manifest{
+		Version:   Version(),
+		InputHash: inputHash,
+		Files:     make(map[string]*ManifestFile),
+	}
+	for _, result := range runtime.Results {
+		for _, file := range result.Files {
+			name := FSName(file.RelPath)
+			m.Files[name] = &ManifestFile{
+				SourceHash: runtime.sourceHashes[name],
+				OutputHash: Hash([]byte(file.Content)),
+			}
+		}
+	}
+	return writeManifest(runtime.FS, m)
+}
@@ -0,0 +1,288 @@
+package genx
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	gqlast "github.com/vektah/gqlparser/v2/ast"
+	gqlparser "github.com/vektah/gqlparser/v2/parser"
+)
+
+// MergeStrategy declares a file as a fragment which can be combined with the fragments of the same path
+// generated by other extensions, all fragments of a path must use the same strategy.
+type MergeStrategy string
+
+const (
+	// MergeGo combines the declarations of Go files of the same package and unions their imports
+	MergeGo MergeStrategy = "go"
+	// MergeGraphQL combines the definitions of GraphQL schema documents
+	MergeGraphQL MergeStrategy = "graphql"
+	// MergeAppend concatenates the contents
+	MergeAppend MergeStrategy = "append"
+)
+
+// mergeFragments merges the fragments generated by ext into the files of the same path generated before,
+// following the order of the extensions so that the result is deterministic.
+func mergeFragments(runtime *Runtime, ext Extension, result *Result) error {
+	bases := make(map[string]*File)
+	for _, prev := range runtime.Config.Extensions {
+		if prev == ext {
+			break
+		}
+		if prevResult, ok := runtime.Results[prev.Name()]; ok {
+			for _, file := range prevResult.Files {
+				name := FSName(file.RelPath)
+				if _, ok := bases[name]; !ok {
+					bases[name] = file
+				}
+				if _, ok := runtime.contributors[name]; !ok {
+					runtime.contributors[name] = []string{prev.Name()}
+				}
+			}
+		}
+	}
+
+	var errs Diagnostics
+	files := make([]*File, 0, len(result.Files))
+	for _, file := range result.Files {
+		name := FSName(file.RelPath)
+		base, ok := bases[name]
+		if !ok || file.Merge == "" || base.Merge == "" {
+			// the files generated twice without opting in are reported by checkDuplicateFiles
+			if !ok {
+				bases[name] = file
+				runtime.contributors[name] = []string{ext.Name()}
+			}
+			files = append(files, file)
+			continue
+		}
+
+		contributors := runtime.contributors[name]
+		if file.Merge != base.Merge {
+			errs = append(errs, &Diagnostic{
+				Severity: SeverityError,
+				Message: fmt.Sprintf("cannot merge the %s fragment of %s into the %s fragment of %s",
+					file.Merge, ext.Name(), base.Merge, strings.Join(contributors, ", ")),
+				File:      name,
+				Extension: ext.Name(),
+			})
+			continue
+		}
+		merged, err := mergeContent(file.Merge, base.Content, file.Content)
+		if err != nil {
+			errs = append(errs, &Diagnostic{
+				Severity: SeverityError,
+				Message: fmt.Sprintf("failed to merge the fragment of %s into the one of %s: %s",
+					ext.Name(), strings.Join(contributors, ", "), err.Error()),
+				File:      name,
+				Extension: ext.Name(),
+				Err:       err,
+			})
+			continue
+		}
+		base.Content = merged
+		if !lo.Contains(contributors, ext.Name()) {
+			runtime.contributors[name] = append(contributors, ext.Name())
+		}
+	}
+	result.Files = files
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func mergeContent(strategy MergeStrategy, base, fragment string) (string, error) {
+	switch strategy {
+	case MergeGo:
+		return mergeGo(base, fragment)
+	case MergeGraphQL:
+		return mergeGraphQL(base, fragment)
+	case MergeAppend:
+		if base != "" && !strings.HasSuffix(base, "\n") {
+			base += "\n"
+		}
+		return base + fragment, nil
+	}
+	return "", errors.Errorf("unknown merge strategy %q", strategy)
+}
+
+type goFragment struct {
+	file    *ast.File
+	fset    *token.FileSet
+	imports []*ast.ImportSpec
+	// body is the source after the imports
+	body string
+}
+
+func parseGoFragment(src string) (*goFragment, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	end := f.Name.End()
+	for _, decl := range f.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.IMPORT {
+			end = genDecl.End()
+		}
+	}
+	return &goFragment{
+		file:    f,
+		fset:    fset,
+		imports: f.Imports,
+		body:    src[fset.Position(end).Offset:],
+	}, nil
+}
+
+// declNames returns the names of the package level declarations, methods are named Type.Method.
+func (f *goFragment) declNames() map[string]int {
+	names := make(map[string]int)
+	for _, decl := range f.file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				name = receiverName(decl.Recv.List[0].Type) + "." + name
+			} else if name == "init" {
+				continue
+			}
+			names[name] = f.fset.Position(decl.Pos()).Line
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names[spec.Name.Name] = f.fset.Position(spec.Pos()).Line
+				case *ast.ValueSpec:
+					for _, ident := range spec.Names {
+						if ident.Name != "_" {
+							names[ident.Name] = f.fset.Position(ident.Pos()).Line
+						}
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// mergeGo appends the declarations of fragment to base and unions the imports of both,
+// the header and the package clause of base are kept.
+func mergeGo(base, fragment string) (string, error) {
+	b, err := parseGoFragment(base)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the merged content")
+	}
+	f, err := parseGoFragment(fragment)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the fragment")
+	}
+	if b.file.Name.Name != f.file.Name.Name {
+		return "", errors.Errorf("package %s conflicts with package %s", f.file.Name.Name, b.file.Name.Name)
+	}
+
+	baseNames := b.declNames()
+	var conflicts []string
+	for name, line := range f.declNames() {
+		if baseLine, ok := baseNames[name]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%s (fragment line %d, already declared at line %d)", name, line, baseLine))
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return "", errors.Errorf("conflicting declarations: %s", strings.Join(conflicts, ", "))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base[:b.fset.Position(b.file.Name.End()).Offset])
+	sb.WriteString("\n\n")
+
+	seen := make(map[string]bool)
+	var imports []string
+	for _, spec := range append(b.imports, f.imports...) {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		line := strconv.Quote(path)
+		if spec.Name != nil {
+			line = spec.Name.Name + " " + line
+		}
+		if !seen[line] {
+			seen[line] = true
+			imports = append(imports, line)
+		}
+	}
+	if len(imports) > 0 {
+		sb.WriteString("import (\n")
+		for _, line := range imports {
+			sb.WriteString("\t" + line + "\n")
+		}
+		sb.WriteString(")\n")
+	}
+
+	sb.WriteString(strings.TrimRight(b.body, "\n"))
+	sb.WriteString("\n\n")
+	sb.WriteString(strings.TrimLeft(f.body, "\n"))
+	return sb.String(), nil
+}
+
+// mergeGraphQL appends the definitions of fragment to base, a type or directive may be defined once only,
+// fragments can use extend to add fields to the types of others.
+func mergeGraphQL(base, fragment string) (string, error) {
+	b, err := gqlparser.ParseSchema(&gqlast.Source{Name: "merged", Input: base})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the merged content")
+	}
+	f, err := gqlparser.ParseSchema(&gqlast.Source{Name: "fragment", Input: fragment})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the fragment")
+	}
+
+	defined := make(map[string]int)
+	for _, def := range b.Definitions {
+		defined[def.Name] = def.Position.Line
+	}
+	for _, def := range b.Directives {
+		defined["@"+def.Name] = def.Position.Line
+	}
+	var conflicts []string
+	check := func(name string, pos *gqlast.Position) {
+		if line, ok := defined[name]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%s (fragment line %d, already defined at line %d)", name, pos.Line, line))
+		}
+	}
+	for _, def := range f.Definitions {
+		check(def.Name, def.Position)
+	}
+	for _, def := range f.Directives {
+		check("@"+def.Name, def.Position)
+	}
+	if len(f.Schema) > 0 && len(b.Schema) > 0 {
+		conflicts = append(conflicts, fmt.Sprintf("schema (fragment line %d, already defined at line %d)", f.Schema[0].Position.Line, b.Schema[0].Position.Line))
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return "", errors.Errorf("conflicting definitions: %s", strings.Join(conflicts, ", "))
+	}
+
+	return strings.TrimRight(base, "\n") + "\n\n" + strings.TrimLeft(fragment, "\n"), nil
+}
@@ -0,0 +1,130 @@
+package genx_test
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/extension/relayext"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fragmentExtension struct {
+	genx.DefaultExtension
+	name  string
+	files []*genx.File
+}
+
+func (e *fragmentExtension) Name() string {
+	return e.name
+}
+
+func (e *fragmentExtension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result, error) {
+	return &genx.Result{Files: e.files}, nil
+}
+
+func (e *fragmentExtension) RunsAfter() []string {
+	return []string{"relayext"}
+}
+
+func (e *fragmentExtension) RunsBefore() []string {
+	return nil
+}
+
+const mergePrototype = `type User @node {
+  name: String!
+}
+`
+
+func TestMergeFragments(t *testing.T) {
+	fsys := newPrototypeFS(t, mergePrototype)
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(relayext.New(), &fragmentExtension{
+		name: "audit",
+		files: []*genx.File{
+			{
+				RelPath: "server/resolver/resolver.genx.go",
+				Content: "package resolver\n\nimport \"log\"\n\nfunc (r *Resolver) Audit(msg string) {\n\tlog.Println(msg)\n}\n",
+				Merge:   genx.MergeGo,
+			},
+			{
+				RelPath: "schema/schema.genx.graphql",
+				Content: "extend type User {\n  audited: Boolean!\n}\n",
+				Merge:   genx.MergeGraphQL,
+			},
+			{RelPath: "CHANGELOG.md", Content: "- relay\n", Merge: genx.MergeAppend},
+		},
+	}, &fragmentExtension{
+		name:  "notes",
+		files: []*genx.File{{RelPath: "CHANGELOG.md", Content: "- notes\n", Merge: genx.MergeAppend}},
+	}))
+	require.NoError(t, err)
+
+	resolver, err := fs.ReadFile(fsys, "server/resolver/resolver.genx.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(resolver), "type Resolver struct")
+	assert.Contains(t, string(resolver), "func (r *Resolver) Audit(msg string) {")
+	assert.Contains(t, string(resolver), "\t\"log\"\n")
+
+	schema, err := fs.ReadFile(fsys, "schema/schema.genx.graphql")
+	require.NoError(t, err)
+	assert.Contains(t, string(schema), "type User {")
+	assert.Contains(t, string(schema), "extend type User {\n  audited: Boolean!\n}\n")
+
+	changelog, err := fs.ReadFile(fsys, "CHANGELOG.md")
+	require.NoError(t, err)
+	assert.Equal(t, "- relay\n- notes\n", string(changelog))
+}
+
+func TestMergeFragmentsConflicts(t *testing.T) {
+	generate := func(files ...*genx.File) genx.Diagnostics {
+		err := genx.Generate(context.Background(), &genx.Config{
+			FS:                  newPrototypeFS(t, mergePrototype),
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/example/app",
+		}, genx.Extensions(relayext.New(), &fragmentExtension{name: "audit", files: files}))
+		var ds genx.Diagnostics
+		require.True(t, errors.As(err, &ds), "%v", err)
+		return ds
+	}
+
+	ds := generate(&genx.File{
+		RelPath: "server/resolver/resolver.genx.go",
+		Content: "package resolver\n\ntype Resolver struct{}\n\nfunc (r *Resolver) DB() {}\n",
+		Merge:   genx.MergeGo,
+	})
+	require.Len(t, ds, 1)
+	assert.Equal(t, "server/resolver/resolver.genx.go", ds[0].File)
+	assert.Equal(t, "audit", ds[0].Extension)
+	assert.Regexp(t, `failed to merge the fragment of audit into the one of relayext: conflicting declarations: `+
+		`Resolver \(fragment line 3, already declared at line \d+\), Resolver.DB \(fragment line 5, already declared at line \d+\)`, ds[0].Message)
+
+	ds = generate(&genx.File{
+		RelPath: "schema/schema.genx.graphql",
+		Content: "type User {\n  id: ID!\n}\n",
+		Merge:   genx.MergeGraphQL,
+	})
+	require.Len(t, ds, 1)
+	assert.Regexp(t, `conflicting definitions: User \(fragment line 1, already defined at line \d+\)`, ds[0].Message)
+
+	ds = generate(&genx.File{
+		RelPath: "server/model/models.genx.go",
+		Content: "package model\n",
+		Merge:   genx.MergeAppend,
+	})
+	require.Len(t, ds, 1)
+	assert.Equal(t, "cannot merge the append fragment of audit into the go fragment of relayext", ds[0].Message)
+
+	ds = generate(&genx.File{
+		RelPath: "server/model/models.genx.go",
+		Content: "package model\n",
+	})
+	require.Len(t, ds, 1)
+	assert.Contains(t, ds[0].Message, "duplicate files detected")
+}
@@ -1,5 +1,7 @@
 package genx
 
+import "log/slog"
+
 type Option func(*Config) error
 
 func Extensions(extensions ...Extension) Option {
@@ -10,3 +12,40 @@ func Extensions(extensions ...Extension) Option {
 		return nil
 	}
 }
+
+// DryRun records the planned changes into changeset instead of touching the output dir.
+func DryRun(changeset *Changeset) Option {
+	return func(conf *Config) error {
+		conf.DryRun = true
+		conf.Changeset = changeset
+		return nil
+	}
+}
+
+func OnDiagnostic(fn func(d *Diagnostic)) Option {
+	return func(conf *Config) error {
+		conf.OnDiagnostic = fn
+		return nil
+	}
+}
+
+func Logger(logger *slog.Logger) Option {
+	return func(conf *Config) error {
+		conf.Logger = logger
+		return nil
+	}
+}
+
+func ShareFormatCache(cache *FormatCache) Option {
+	return func(conf *Config) error {
+		conf.FormatCache = cache
+		return nil
+	}
+}
+
+func OnEvent(fn func(e *Event)) Option {
+	return func(conf *Config) error {
+		conf.OnEvent = fn
+		return nil
+	}
+}
@@ -0,0 +1,119 @@
+package genx
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AllExtensions can be returned from RunsAfter to run after every extension that does not use it itself.
+const AllExtensions = "*"
+
+// WithDependencies is implemented by extensions that consume the results of other extensions,
+// the dependencies must be configured and run before.
+type WithDependencies interface {
+	DependsOn() []string
+}
+
+// WithOrdering is implemented by extensions that must run before or after others if they are configured.
+type WithOrdering interface {
+	RunsBefore() []string
+	RunsAfter() []string
+}
+
+// sortExtensions orders the extensions topologically by their declared dependencies,
+// the configured order is kept for the extensions without constraints between them.
+func sortExtensions(extensions []Extension) ([]Extension, error) {
+	index := make(map[string]int, len(extensions))
+	for i, ext := range extensions {
+		index[ext.Name()] = i
+	}
+
+	// edges[i] contains the extensions that must run after extensions[i]
+	edges := make([]map[int]bool, len(extensions))
+	for i := range extensions {
+		edges[i] = make(map[int]bool)
+	}
+	addEdge := func(from, to int) {
+		if from != to {
+			edges[from][to] = true
+		}
+	}
+
+	runsAfterAll := make([]bool, len(extensions))
+	for i, ext := range extensions {
+		if o, ok := ext.(WithOrdering); ok {
+			runsAfterAll[i] = slices.Contains(o.RunsAfter(), AllExtensions)
+		}
+	}
+
+	for i, ext := range extensions {
+		if d, ok := ext.(WithDependencies); ok {
+			for _, dep := range d.DependsOn() {
+				j, ok := index[dep]
+				if !ok {
+					return nil, errors.Errorf("extension %s depends on %s which is not configured", ext.Name(), dep)
+				}
+				addEdge(j, i)
+			}
+		}
+		if o, ok := ext.(WithOrdering); ok {
+			for _, name := range o.RunsAfter() {
+				if name == AllExtensions {
+					for j := range extensions {
+						if !runsAfterAll[j] {
+							addEdge(j, i)
+						}
+					}
+					continue
+				}
+				if j, ok := index[name]; ok {
+					addEdge(j, i)
+				}
+			}
+			for _, name := range o.RunsBefore() {
+				if j, ok := index[name]; ok {
+					addEdge(i, j)
+				}
+			}
+		}
+	}
+
+	inDegree := make([]int, len(extensions))
+	for _, tos := range edges {
+		for to := range tos {
+			inDegree[to]++
+		}
+	}
+
+	sorted := make([]Extension, 0, len(extensions))
+	done := make([]bool, len(extensions))
+	for len(sorted) < len(extensions) {
+		// pick the first ready extension in configured order to keep the result stable
+		next := -1
+		for i := range extensions {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var cycle []string
+			for i, ext := range extensions {
+				if !done[i] {
+					cycle = append(cycle, ext.Name())
+				}
+			}
+			sort.Strings(cycle)
+			return nil, errors.Errorf("dependency cycle detected between extensions: %s", strings.Join(cycle, ", "))
+		}
+		done[next] = true
+		sorted = append(sorted, extensions[next])
+		for to := range edges[next] {
+			inDegree[to]--
+		}
+	}
+	return sorted, nil
+}
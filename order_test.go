@@ -0,0 +1,69 @@
+package genx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderedExtension struct {
+	DefaultExtension
+	name      string
+	dependsOn []string
+	before    []string
+	after     []string
+}
+
+func (e *orderedExtension) Name() string         { return e.name }
+func (e *orderedExtension) DependsOn() []string  { return e.dependsOn }
+func (e *orderedExtension) RunsBefore() []string { return e.before }
+func (e *orderedExtension) RunsAfter() []string  { return e.after }
+
+func names(extensions []Extension) []string {
+	var names []string
+	for _, ext := range extensions {
+		names = append(names, ext.Name())
+	}
+	return names
+}
+
+func TestSortExtensions(t *testing.T) {
+	{
+		sorted, err := sortExtensions([]Extension{
+			&orderedExtension{name: "cleanup", after: []string{AllExtensions}},
+			&orderedExtension{name: "gosurgery", after: []string{"relayext"}},
+			&orderedExtension{name: "gqlgenext"},
+			&orderedExtension{name: "relayext"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gqlgenext", "relayext", "gosurgery", "cleanup"}, names(sorted))
+	}
+
+	{
+		// unconstrained extensions keep the configured order
+		sorted, err := sortExtensions([]Extension{
+			&orderedExtension{name: "b"},
+			&orderedExtension{name: "a", before: []string{"missing"}},
+			&orderedExtension{name: "c", dependsOn: []string{"a"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a", "c"}, names(sorted))
+	}
+
+	{
+		_, err := sortExtensions([]Extension{
+			&orderedExtension{name: "a", dependsOn: []string{"missing"}},
+		})
+		require.ErrorContains(t, err, "extension a depends on missing which is not configured")
+	}
+
+	{
+		_, err := sortExtensions([]Extension{
+			&orderedExtension{name: "a", dependsOn: []string{"b"}},
+			&orderedExtension{name: "b", after: []string{"a"}},
+			&orderedExtension{name: "c"},
+		})
+		require.ErrorContains(t, err, "dependency cycle detected between extensions: a, b")
+	}
+}
@@ -1,23 +1,94 @@
 package gqlx
 
 import (
-	"os"
+	"io/fs"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
 )
 
+// FormatDocument formats the document with the top-level definitions separated by blank lines.
 func FormatDocument(doc *ast.SchemaDocument) string {
-	sb := &strings.Builder{}
-	formatter.NewFormatter(sb, formatter.WithIndent("  "), formatter.WithComments()).FormatSchemaDocument(doc)
-	return sb.String()
+	var blocks []*ast.SchemaDocument
+	if len(doc.Schema) > 0 {
+		blocks = append(blocks, &ast.SchemaDocument{Schema: doc.Schema})
+	}
+	if len(doc.SchemaExtension) > 0 {
+		blocks = append(blocks, &ast.SchemaDocument{SchemaExtension: doc.SchemaExtension})
+	}
+	for _, def := range doc.Directives {
+		blocks = append(blocks, &ast.SchemaDocument{Directives: ast.DirectiveDefinitionList{def}})
+	}
+	for _, def := range doc.Definitions {
+		blocks = append(blocks, &ast.SchemaDocument{Definitions: ast.DefinitionList{def}})
+	}
+	for _, def := range doc.Extensions {
+		blocks = append(blocks, &ast.SchemaDocument{Extensions: ast.DefinitionList{def}})
+	}
+	if doc.Comment != nil && len(doc.Comment.List) > 0 {
+		blocks = append(blocks, &ast.SchemaDocument{Comment: doc.Comment})
+	}
+
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		sb := &strings.Builder{}
+		formatter.NewFormatter(sb, formatter.WithIndent("  "), formatter.WithComments()).FormatSchemaDocument(block)
+		if text := sb.String(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// FormatSchema parses and formats a schema source, the leading comment block followed by a blank line
+// is kept as the header of the file.
+func FormatSchema(source *ast.Source) (string, error) {
+	header, body := splitHeader(source.Input)
+	// keep the line numbers of the errors
+	input := strings.Repeat("\n", strings.Count(header, "\n")) + body
+	doc, err := parser.ParseSchema(&ast.Source{Name: source.Name, Input: input})
+	if err != nil {
+		return "", err
+	}
+	formatted := FormatDocument(doc)
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return formatted, nil
+	}
+	if formatted == "" {
+		return header + "\n", nil
+	}
+	return header + "\n\n" + formatted, nil
+}
+
+func splitHeader(input string) (header, body string) {
+	lines := strings.SplitAfter(input, "\n")
+	end := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			if end > 0 {
+				return strings.Join(lines[:i+1], ""), strings.Join(lines[i+1:], "")
+			}
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		end = i + 1
+	}
+	return "", input
 }
 
-func LoadSources(pattern string) ([]*ast.Source, error) {
-	matchedFiles, err := filepath.Glob(pattern)
+// LoadSources loads the schema sources in fsys matching pattern as Glob does, named by their path in fsys.
+func LoadSources(fsys fs.FS, pattern string) ([]*ast.Source, error) {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	matchedFiles, err := Glob(fsys, pattern)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to glob files matching pattern %s", pattern)
 	}
@@ -26,11 +97,11 @@ func LoadSources(pattern string) ([]*ast.Source, error) {
 	}
 	var sources []*ast.Source
 	for _, file := range matchedFiles {
-		content, err := os.ReadFile(file)
+		content, err := fs.ReadFile(fsys, file)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to read file %s", file)
 		}
-		sources = append(sources, &ast.Source{Name: filepath.Base(file), Input: string(content)})
+		sources = append(sources, &ast.Source{Name: file, Input: string(content)})
 	}
 	return sources, nil
 }
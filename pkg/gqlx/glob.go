@@ -0,0 +1,81 @@
+package gqlx
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MatchPath reports whether the slash separated name matches pattern, a path.Match pattern
+// in which a ** element matches any number of directories.
+func MatchPath(pattern, name string) (bool, error) {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if ok, err := matchElems(pattern[1:], name[i:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if !ok || err != nil {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
+
+// PatternDir returns the directory of pattern before its first wildcard.
+func PatternDir(pattern string) string {
+	dir := path.Dir(pattern)
+	for dir != "." && dir != "/" && strings.ContainsAny(dir, "*?[\\") {
+		dir = path.Dir(dir)
+	}
+	return dir
+}
+
+// Glob returns the names of the files in fsys matching pattern as MatchPath does, hidden directories are skipped by **.
+func Glob(fsys fs.FS, pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return fs.Glob(fsys, pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	root := PatternDir(pattern)
+	var matches []string
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			if name != root && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		ok, err := MatchPath(pattern, name)
+		if ok {
+			matches = append(matches, name)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
@@ -0,0 +1,282 @@
+package gqlx
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type introspectionResult struct {
+	Data *struct {
+		Schema *introspectionSchema `json:"__schema"`
+	} `json:"data"`
+	Schema *introspectionSchema `json:"__schema"`
+}
+
+type introspectionSchema struct {
+	QueryType        *introspectionTypeRef     `json:"queryType"`
+	MutationType     *introspectionTypeRef     `json:"mutationType"`
+	SubscriptionType *introspectionTypeRef     `json:"subscriptionType"`
+	Types            []*introspectionType      `json:"types"`
+	Directives       []*introspectionDirective `json:"directives"`
+}
+
+type introspectionType struct {
+	Kind           string                     `json:"kind"`
+	Name           string                     `json:"name"`
+	Description    string                     `json:"description"`
+	SpecifiedByURL string                     `json:"specifiedByURL"`
+	Fields         []*introspectionField      `json:"fields"`
+	InputFields    []*introspectionInputValue `json:"inputFields"`
+	Interfaces     []*introspectionTypeRef    `json:"interfaces"`
+	EnumValues     []*introspectionEnumValue  `json:"enumValues"`
+	PossibleTypes  []*introspectionTypeRef    `json:"possibleTypes"`
+}
+
+type introspectionField struct {
+	Name              string                     `json:"name"`
+	Description       string                     `json:"description"`
+	Args              []*introspectionInputValue `json:"args"`
+	Type              *introspectionTypeRef      `json:"type"`
+	IsDeprecated      bool                       `json:"isDeprecated"`
+	DeprecationReason *string                    `json:"deprecationReason"`
+}
+
+type introspectionInputValue struct {
+	Name              string                `json:"name"`
+	Description       string                `json:"description"`
+	Type              *introspectionTypeRef `json:"type"`
+	DefaultValue      *string               `json:"defaultValue"`
+	IsDeprecated      bool                  `json:"isDeprecated"`
+	DeprecationReason *string               `json:"deprecationReason"`
+}
+
+type introspectionEnumValue struct {
+	Name              string  `json:"name"`
+	Description       string  `json:"description"`
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
+}
+
+type introspectionDirective struct {
+	Name         string                     `json:"name"`
+	Description  string                     `json:"description"`
+	Locations    []string                   `json:"locations"`
+	Args         []*introspectionInputValue `json:"args"`
+	IsRepeatable bool                       `json:"isRepeatable"`
+}
+
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   string                `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+// builtInScalars and builtInDirectives are declared by the prelude of gqlparser
+var (
+	builtInScalars    = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+	builtInDirectives = map[string]bool{"skip": true, "include": true, "deprecated": true, "specifiedBy": true, "defer": true, "oneOf": true}
+)
+
+// IntrospectionToSDL converts the JSON result of an introspection query, with or without its data envelope,
+// into a formatted schema document. The introspection and built-in types and directives are omitted.
+func IntrospectionToSDL(name string, data []byte) (string, error) {
+	var result introspectionResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", errors.Wrapf(err, "failed to decode introspection result %s", name)
+	}
+	schema := result.Schema
+	if result.Data != nil && result.Data.Schema != nil {
+		schema = result.Data.Schema
+	}
+	if schema == nil {
+		return "", errors.Errorf("introspection result %s has no __schema", name)
+	}
+
+	w := &sdlWriter{}
+	w.schema(schema)
+	for _, d := range schema.Directives {
+		if !builtInDirectives[d.Name] {
+			w.directive(d)
+		}
+	}
+	for _, t := range schema.Types {
+		if strings.HasPrefix(t.Name, "__") || (t.Kind == "SCALAR" && builtInScalars[t.Name]) {
+			continue
+		}
+		if err := w.definition(t); err != nil {
+			return "", errors.Wrapf(err, "failed to convert introspection result %s", name)
+		}
+	}
+
+	sdl, err := FormatSchema(&ast.Source{Name: name, Input: w.String()})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to convert introspection result %s", name)
+	}
+	return sdl, nil
+}
+
+type sdlWriter struct {
+	bytes.Buffer
+}
+
+func (w *sdlWriter) schema(s *introspectionSchema) {
+	roots := []struct {
+		operation string
+		ref       *introspectionTypeRef
+		name      string
+	}{
+		{"query", s.QueryType, "Query"},
+		{"mutation", s.MutationType, "Mutation"},
+		{"subscription", s.SubscriptionType, "Subscription"},
+	}
+	custom := false
+	for _, root := range roots {
+		if root.ref != nil && root.ref.Name != root.name {
+			custom = true
+		}
+	}
+	if !custom {
+		return
+	}
+	w.WriteString("schema {\n")
+	for _, root := range roots {
+		if root.ref != nil {
+			w.WriteString("  " + root.operation + ": " + root.ref.Name + "\n")
+		}
+	}
+	w.WriteString("}\n")
+}
+
+func (w *sdlWriter) directive(d *introspectionDirective) {
+	w.description(d.Description)
+	w.WriteString("directive @" + d.Name)
+	w.args(d.Args)
+	if d.IsRepeatable {
+		w.WriteString(" repeatable")
+	}
+	w.WriteString(" on " + strings.Join(d.Locations, " | ") + "\n")
+}
+
+func (w *sdlWriter) definition(t *introspectionType) error {
+	w.description(t.Description)
+	switch t.Kind {
+	case "SCALAR":
+		w.WriteString("scalar " + t.Name)
+		if t.SpecifiedByURL != "" {
+			w.WriteString(" @specifiedBy(url: " + quote(t.SpecifiedByURL) + ")")
+		}
+		w.WriteString("\n")
+	case "OBJECT", "INTERFACE":
+		keyword := "type"
+		if t.Kind == "INTERFACE" {
+			keyword = "interface"
+		}
+		w.WriteString(keyword + " " + t.Name)
+		for i, iface := range t.Interfaces {
+			if i == 0 {
+				w.WriteString(" implements ")
+			} else {
+				w.WriteString(" & ")
+			}
+			w.WriteString(iface.Name)
+		}
+		w.WriteString(" {\n")
+		for _, f := range t.Fields {
+			w.description(f.Description)
+			w.WriteString(f.Name)
+			w.args(f.Args)
+			w.WriteString(": " + typeRef(f.Type))
+			w.deprecated(f.IsDeprecated, f.DeprecationReason)
+			w.WriteString("\n")
+		}
+		w.WriteString("}\n")
+	case "UNION":
+		names := make([]string, 0, len(t.PossibleTypes))
+		for _, possible := range t.PossibleTypes {
+			names = append(names, possible.Name)
+		}
+		w.WriteString("union " + t.Name + " = " + strings.Join(names, " | ") + "\n")
+	case "ENUM":
+		w.WriteString("enum " + t.Name + " {\n")
+		for _, v := range t.EnumValues {
+			w.description(v.Description)
+			w.WriteString(v.Name)
+			w.deprecated(v.IsDeprecated, v.DeprecationReason)
+			w.WriteString("\n")
+		}
+		w.WriteString("}\n")
+	case "INPUT_OBJECT":
+		w.WriteString("input " + t.Name + " {\n")
+		for _, v := range t.InputFields {
+			w.inputValue(v)
+			w.WriteString("\n")
+		}
+		w.WriteString("}\n")
+	default:
+		return errors.Errorf("unknown kind %s of type %s", t.Kind, t.Name)
+	}
+	return nil
+}
+
+func (w *sdlWriter) args(args []*introspectionInputValue) {
+	if len(args) == 0 {
+		return
+	}
+	w.WriteString("(")
+	for i, arg := range args {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.inputValue(arg)
+	}
+	w.WriteString(")")
+}
+
+func (w *sdlWriter) inputValue(v *introspectionInputValue) {
+	w.description(v.Description)
+	w.WriteString(v.Name + ": " + typeRef(v.Type))
+	if v.DefaultValue != nil {
+		w.WriteString(" = " + *v.DefaultValue)
+	}
+	w.deprecated(v.IsDeprecated, v.DeprecationReason)
+}
+
+func (w *sdlWriter) description(description string) {
+	if description != "" {
+		w.WriteString(quote(description) + " ")
+	}
+}
+
+func (w *sdlWriter) deprecated(isDeprecated bool, reason *string) {
+	if !isDeprecated {
+		return
+	}
+	w.WriteString(" @deprecated")
+	if reason != nil && *reason != "" {
+		w.WriteString("(reason: " + quote(*reason) + ")")
+	}
+}
+
+func typeRef(ref *introspectionTypeRef) string {
+	switch ref.Kind {
+	case "NON_NULL":
+		return typeRef(ref.OfType) + "!"
+	case "LIST":
+		return "[" + typeRef(ref.OfType) + "]"
+	default:
+		return ref.Name
+	}
+}
+
+// quote returns s as a GraphQL string value, whose escapes are a superset of the JSON ones.
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,54 @@
+package genx
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+func (c *Config) concurrency() int {
+	if c.Concurrency > 0 {
+		return c.Concurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// forEachFile calls fn for every file of the results with a bounded number of workers,
+// every failure is collected instead of stopping at the first one, a panic of fn is reported on its file.
+func forEachFile(ctx context.Context, runtime *Runtime, fn func(ctx context.Context, file *File) error) error {
+	var files []*File
+	for _, result := range runtime.Results {
+		files = append(files, result.Files...)
+	}
+	// report errors in a stable order
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].RelPath < files[j].RelPath
+	})
+
+	errs := make([]error, len(files))
+	sem := make(chan struct{}, runtime.concurrency())
+	var wg sync.WaitGroup
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					err := panicError(p)
+					errs[i] = &Diagnostic{Severity: SeverityError, Message: err.Error(), File: file.RelPath, Err: err}
+				}
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fn(ctx, file)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
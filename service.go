@@ -0,0 +1,51 @@
+package genx
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotProvided is returned by Lookup when no extension provided the service.
+var ErrNotProvided = errors.New("service not provided")
+
+type service struct {
+	value    any
+	provider string
+}
+
+// Provide makes v available to the other extensions under key for the rest of the run,
+// a key can only be provided by one extension.
+func Provide[T any](r *Runtime, key string, v T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.services == nil {
+		r.services = make(map[string]*service)
+	}
+	if prev, ok := r.services[key]; ok && prev.provider != r.extension {
+		return errors.Errorf("service %q is already provided by %s", key, prev.provider)
+	}
+	r.services[key] = &service{value: v, provider: r.extension}
+	return nil
+}
+
+// Lookup returns the service provided under key, the providing extension must run before,
+// which is best declared with WithDependencies.
+func Lookup[T any](r *Runtime, key string) (T, error) {
+	var zero T
+	r.mu.Lock()
+	s, ok := r.services[key]
+	consumer := r.extension
+	r.mu.Unlock()
+	if !ok {
+		if consumer == "" {
+			return zero, errors.Wrapf(ErrNotProvided, "service %q", key)
+		}
+		return zero, errors.Wrapf(ErrNotProvided, "%s requires service %q but no extension running before it provides it", consumer, key)
+	}
+	v, ok := s.value.(T)
+	if !ok {
+		return zero, errors.Errorf("service %q provided by %s is a %T, not a %s", key, s.provider, s.value, reflect.TypeFor[T]())
+	}
+	return v, nil
+}
@@ -0,0 +1,103 @@
+package genx_test
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/extension/relayext"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nodeListExtension struct {
+	genx.DefaultExtension
+}
+
+func (e *nodeListExtension) Name() string {
+	return "nodelist"
+}
+
+func (e *nodeListExtension) DependsOn() []string {
+	return []string{"relayext"}
+}
+
+func (e *nodeListExtension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result, error) {
+	data, err := relayext.LookupData(r)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, node := range data.Nodes {
+		names = append(names, node.Name)
+	}
+	return &genx.Result{Files: []*genx.File{
+		{RelPath: "nodes.genx.txt", Content: strings.Join(names, "\n") + "\n"},
+	}}, nil
+}
+
+type providingExtension struct {
+	genx.DefaultExtension
+	name  string
+	value any
+}
+
+func (e *providingExtension) Name() string {
+	return e.name
+}
+
+func (e *providingExtension) BeforeGenerate(ctx context.Context, r *genx.Runtime) error {
+	return genx.Provide(r, relayext.DataKey, e.value)
+}
+
+func TestProvideLookup(t *testing.T) {
+	fsys := newPrototypeFS(t, "type User @node {\n  name: String!\n}\n\ntype Post @node {\n  title: String!\n}\n")
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:                  fsys,
+		PrototypeRelPattern: "prototype.graphql",
+		GoModule:            "github.com/example/app",
+	}, genx.Extensions(&nodeListExtension{}, relayext.New()))
+	require.NoError(t, err)
+	content, err := fs.ReadFile(fsys, "nodes.genx.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "Post\nUser\n", string(content))
+}
+
+func TestLookupErrors(t *testing.T) {
+	generate := func(extensions ...genx.Extension) error {
+		return genx.Generate(context.Background(), &genx.Config{
+			FS:                  newPrototypeFS(t, "type User @node {\n  name: String!\n}\n"),
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/example/app",
+		}, genx.Extensions(extensions...))
+	}
+
+	// the data is provided by relayext during its generation only
+	err := generate(relayext.New(), &lookupBeforeExtension{})
+	require.ErrorIs(t, err, genx.ErrNotProvided)
+	assert.ErrorContains(t, err, `lookup requires service "relayext.data" but no extension running before it provides it`)
+
+	err = generate(&providingExtension{name: "fake", value: "not data"}, &lookupBeforeExtension{})
+	var ds genx.Diagnostics
+	require.True(t, errors.As(err, &ds))
+	assert.Contains(t, ds[0].Message, `service "relayext.data" provided by fake is a string, not a *relayext.Data`)
+
+	err = generate(&providingExtension{name: "a", value: 1}, &providingExtension{name: "b", value: 2})
+	assert.ErrorContains(t, err, `service "relayext.data" is already provided by a`)
+}
+
+type lookupBeforeExtension struct {
+	genx.DefaultExtension
+}
+
+func (e *lookupBeforeExtension) Name() string {
+	return "lookup"
+}
+
+func (e *lookupBeforeExtension) BeforeGenerate(ctx context.Context, r *genx.Runtime) error {
+	_, err := relayext.LookupData(r)
+	return err
+}
@@ -0,0 +1,112 @@
+package genx
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+
+	"github.com/molon/genx/pkg/gqlx"
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SchemaSource selects prototype files, the sources are named by their slash separated path in FS
+// so that errors point at the right file.
+type SchemaSource struct {
+	// Patterns are slash separated globs, a ** element matches any number of directories
+	Patterns []string
+	// Excludes are globs like Patterns removing files from the matches
+	Excludes []string
+	// FS is read instead of the output dir, e.g. an embed.FS
+	FS fs.FS
+	// Introspection marks the files as JSON results of an introspection query, they are converted to SDL
+	Introspection bool
+}
+
+// Match reports whether the file is selected by the source.
+func (s *SchemaSource) Match(name string) bool {
+	return s.matchAny(s.Patterns, name) && !s.matchAny(s.Excludes, name)
+}
+
+func (s *SchemaSource) matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := gqlx.MatchPath(cleanPattern(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Glob returns the files of fsys selected by the source.
+func (s *SchemaSource) Glob(fsys fs.FS) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, pattern := range s.Patterns {
+		matches, err := gqlx.Glob(fsys, cleanPattern(pattern))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to glob files matching pattern %s", pattern)
+		}
+		for _, name := range matches {
+			if !seen[name] && !s.matchAny(s.Excludes, name) {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+func cleanPattern(pattern string) string {
+	return path.Clean(filepath.ToSlash(pattern))
+}
+
+// SchemaSources returns Sources, preceded by a source of PrototypeRelPattern if set.
+func (c *Config) SchemaSources() []*SchemaSource {
+	if c.PrototypeRelPattern == "" {
+		return c.Sources
+	}
+	return append([]*SchemaSource{{Patterns: []string{c.PrototypeRelPattern}}}, c.Sources...)
+}
+
+// LoadSources reads the files selected by the schema sources, a file selected by several sources is read once.
+// Every source must select at least one file.
+func (r *Runtime) LoadSources() ([]*ast.Source, error) {
+	var sources []*ast.Source
+	seen := make(map[string]bool)
+	for _, src := range r.SchemaSources() {
+		fsys := src.FS
+		if fsys == nil {
+			fsys = r.FS
+		}
+		names, err := src.Glob(fsys)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, errors.Errorf("no files found matching patterns %v", src.Patterns)
+		}
+		for _, name := range names {
+			// files of another FS are never deduplicated against the output dir
+			if src.FS == nil {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+			}
+
+			content, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read file %s", name)
+			}
+			input := string(content)
+			if src.Introspection {
+				input, err = gqlx.IntrospectionToSDL(name, content)
+				if err != nil {
+					return nil, err
+				}
+			}
+			sources = append(sources, &ast.Source{Name: name, Input: input})
+		}
+	}
+	return sources, nil
+}
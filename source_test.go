@@ -0,0 +1,115 @@
+package genx_test
+
+import (
+	"context"
+	"path"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/molon/genx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sourcesExtension struct {
+	genx.DefaultExtension
+	names []string
+	types []string
+}
+
+func (e *sourcesExtension) Name() string {
+	return "sources"
+}
+
+func (e *sourcesExtension) Generate(ctx context.Context, r *genx.Runtime) (*genx.Result, error) {
+	sources, err := r.LoadSources()
+	if err != nil {
+		return nil, err
+	}
+	for _, src := range sources {
+		e.names = append(e.names, src.Name)
+	}
+	for name, def := range r.Schema.Types {
+		if !def.BuiltIn {
+			e.types = append(e.types, name)
+		}
+	}
+	sort.Strings(e.types)
+	return &genx.Result{}, nil
+}
+
+const introspection = `{
+  "data": {
+    "__schema": {
+      "queryType": {"name": "Query"},
+      "types": [
+        {"kind": "OBJECT", "name": "Query", "fields": [
+          {"name": "remote", "args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}],
+           "type": {"kind": "OBJECT", "name": "Remote"}}
+        ], "interfaces": []},
+        {"kind": "OBJECT", "name": "Remote", "description": "Remote is defined by another service", "fields": [
+          {"name": "status", "args": [], "type": {"kind": "ENUM", "name": "Status"}, "isDeprecated": true, "deprecationReason": "gone"}
+        ], "interfaces": []},
+        {"kind": "ENUM", "name": "Status", "enumValues": [{"name": "ACTIVE"}, {"name": "INACTIVE"}]},
+        {"kind": "SCALAR", "name": "String"},
+        {"kind": "OBJECT", "name": "__Type", "fields": [], "interfaces": []}
+      ],
+      "directives": [{"name": "skip", "locations": ["FIELD"], "args": []}]
+    }
+  }
+}`
+
+func TestSchemaSources(t *testing.T) {
+	fsys := genx.NewMemFS()
+	for name, content := range map[string]string{
+		"schema/user.graphql":             "type User {\n  name: String!\n}\n",
+		"schema/nested/deep/post.graphql": "type Post {\n  title: String!\n}\n",
+		"schema/nested/draft.graphql":     "type Draft {\n  title: String!\n}\n",
+		"remote/schema.json":              introspection,
+	} {
+		require.NoError(t, fsys.MkdirAll(path.Dir(name), 0o755))
+		require.NoError(t, fsys.WriteFile(name, []byte(content), 0o644))
+	}
+	embedded := fstest.MapFS{
+		"embedded/shared.graphql": {Data: []byte("scalar Time\n")},
+	}
+
+	ext := &sourcesExtension{}
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS: fsys,
+		Sources: []*genx.SchemaSource{
+			{Patterns: []string{"schema/**/*.graphql", "schema/*.graphql"}, Excludes: []string{"**/draft.graphql"}},
+			{Patterns: []string{"embedded/*.graphql"}, FS: embedded},
+			{Patterns: []string{"remote/*.json"}, Introspection: true},
+		},
+	}, genx.Extensions(ext))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"schema/nested/deep/post.graphql",
+		"schema/user.graphql",
+		"embedded/shared.graphql",
+		"remote/schema.json",
+	}, ext.names)
+	assert.Equal(t, []string{"Post", "Query", "Remote", "Status", "Time", "User"}, ext.types)
+}
+
+func TestSchemaSourcesErrors(t *testing.T) {
+	fsys := genx.NewMemFS()
+	require.NoError(t, fsys.MkdirAll("schema/nested", 0o755))
+	require.NoError(t, fsys.WriteFile("schema/nested/user.graphql", []byte("type User {\n  name: Unknown!\n}\n"), 0o644))
+
+	err := genx.Generate(context.Background(), &genx.Config{
+		FS:      fsys,
+		Sources: []*genx.SchemaSource{{Patterns: []string{"schema/**/*.graphql"}}},
+	}, genx.Extensions(&sourcesExtension{}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "schema/nested/user.graphql:2")
+
+	err = genx.Generate(context.Background(), &genx.Config{
+		FS:      fsys,
+		Sources: []*genx.SchemaSource{{Patterns: []string{"missing/**/*.graphql"}}},
+	}, genx.Extensions(&sourcesExtension{}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no files found matching patterns [missing/**/*.graphql]")
+}
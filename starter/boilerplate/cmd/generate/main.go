@@ -5,7 +5,6 @@ package main
 import (
 	"context"
 	"log"
-	"os"
 
 	"github.com/molon/genx"
 	"github.com/molon/genx/extension/cleanup"
@@ -29,11 +28,8 @@ func Generate(ctx context.Context) error {
 	if outputDir == nil || *outputDir == "" {
 		return errors.New("output dir is required")
 	}
-	if err := os.Chdir(*outputDir); err != nil {
-		return errors.Wrap(err, "failed to change working directory")
-	}
 	if err := genx.Generate(ctx, &genx.Config{
-		OutputDir:           ".",
+		OutputDir:           *outputDir,
 		PrototypeRelPattern: "prototype.graphql",
 		GoModule:            "github.com/molon/genx/starter/boilerplate",
 		Extensions: []genx.Extension{
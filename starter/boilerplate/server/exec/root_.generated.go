@@ -123,6 +123,8 @@ type ComplexityRoot struct {
 
 	Query struct {
 		Companies func(childComplexity int, after *string, first *int, before *string, last *int, filterBy *model.CompanyFilter, orderBy []*model.CompanyOrder) int
+		Node      func(childComplexity int, id string) int
+		Nodes     func(childComplexity int, ids []string) int
 		Tasks     func(childComplexity int, after *string, first *int, before *string, last *int, filterBy *model.TaskFilter, orderBy []*model.TaskOrder) int
 		Users     func(childComplexity int, after *string, first *int, before *string, last *int, filterBy *model.UserFilter, orderBy []*model.UserOrder) int
 	}
@@ -570,6 +572,30 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Query.Companies(childComplexity, args["after"].(*string), args["first"].(*int), args["before"].(*string), args["last"].(*int), args["filterBy"].(*model.CompanyFilter), args["orderBy"].([]*model.CompanyOrder)), true
 
+	case "Query.node":
+		if e.complexity.Query.Node == nil {
+			break
+		}
+
+		args, err := ec.field_Query_node_args(context.TODO(), rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Query.Node(childComplexity, args["id"].(string)), true
+
+	case "Query.nodes":
+		if e.complexity.Query.Nodes == nil {
+			break
+		}
+
+		args, err := ec.field_Query_nodes_args(context.TODO(), rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Query.Nodes(childComplexity, args["ids"].([]string)), true
+
 	case "Query.tasks":
 		if e.complexity.Query.Tasks == nil {
 			break
@@ -1015,13 +1041,13 @@ func (ec *executionContext) introspectType(name string) (*introspection.Type, er
 var sources = []*ast.Source{
 	{Name: "../../schema/schema.genx.graphql", Input: `# Code generated by github.com/molon/genx/extension/relayext. DO NOT EDIT.
 
-#
-
 scalar Time
-#
 
 scalar Cursor
-#
+
+interface Node {
+  id: ID!
+}
 
 type PageInfo {
   hasNextPage: Boolean!
@@ -1029,13 +1055,11 @@ type PageInfo {
   startCursor: Cursor
   endCursor: Cursor
 }
-#
 
 enum OrderDirection {
   ASC
   DESC
 }
-#
 
 input StringFilter {
   equals: String
@@ -1052,53 +1076,48 @@ input StringFilter {
   isNull: Boolean
   fold: Boolean
 }
-#
 
 input IntFilter {
-  equals: String
-  not: String
-  in: [String!]
-  notIn: [String!]
-  lt: String
-  lte: String
-  gt: String
-  gte: String
+  equals: Int
+  not: Int
+  in: [Int!]
+  notIn: [Int!]
+  lt: Int
+  lte: Int
+  gt: Int
+  gte: Int
   isNull: Boolean
 }
-#
 
 input FloatFilter {
-  equals: String
-  not: String
-  in: [String!]
-  notIn: [String!]
-  lt: String
-  lte: String
-  gt: String
-  gte: String
+  equals: Float
+  not: Float
+  in: [Float!]
+  notIn: [Float!]
+  lt: Float
+  lte: Float
+  gt: Float
+  gte: Float
   isNull: Boolean
 }
-#
 
 input BooleanFilter {
-  equals: String
-  not: String
+  equals: Boolean
+  not: Boolean
   isNull: Boolean
 }
-#
 
 input TimeFilter {
-  equals: String
-  not: String
-  in: [String!]
-  notIn: [String!]
-  lt: String
-  lte: String
-  gt: String
-  gte: String
+  equals: Time
+  not: Time
+  in: [Time!]
+  notIn: [Time!]
+  lt: Time
+  lte: Time
+  gt: Time
+  gte: Time
   isNull: Boolean
 }
-#
 
 input IDFilter {
   equals: String
@@ -1115,7 +1134,6 @@ input IDFilter {
   isNull: Boolean
   fold: Boolean
 }
-#
 
 input EnumFilter {
   equals: String
@@ -1124,9 +1142,8 @@ input EnumFilter {
   notIn: [String!]
   isNull: Boolean
 }
-#
 
-type Company {
+type Company implements Node {
   id: ID!
   createdAt: Time!
   updatedAt: Time!
@@ -1135,7 +1152,6 @@ type Company {
   employees(after: Cursor, first: Int, before: Cursor, last: Int, filterBy: UserFilter, orderBy: [UserOrder!]): UserConnection!
   viewerPermission: CompanyViewerPermission!
 }
-#
 
 type CompanyConnection {
   nodes: [Company!]!
@@ -1143,13 +1159,11 @@ type CompanyConnection {
   pageInfo: PageInfo!
   totalCount: Int
 }
-#
 
 type CompanyEdge {
   node: Company!
   cursor: Cursor!
 }
-#
 
 input CompanyFilter {
   not: CompanyFilter
@@ -1161,13 +1175,11 @@ input CompanyFilter {
   name: StringFilter
   description: StringFilter
 }
-#
 
 input CompanyOrder {
   field: CompanyOrderField!
   direction: OrderDirection!
 }
-#
 
 enum CompanyOrderField {
   ID
@@ -1176,20 +1188,17 @@ enum CompanyOrderField {
   NAME
   DESCRIPTION
 }
-#
 
 input CreateCompanyInput {
   clientMutationId: String
   name: String!
   description: String
 }
-#
 
 type CreateCompanyPayload {
   clientMutationId: String
   company: Company!
 }
-#
 
 input UpdateCompanyInput {
   clientMutationId: String
@@ -1197,34 +1206,29 @@ input UpdateCompanyInput {
   name: String
   description: String
 }
-#
 
 type UpdateCompanyPayload {
   clientMutationId: String
   company: Company!
 }
-#
 
 input DeleteCompanyInput {
   clientMutationId: String
   companyId: ID!
 }
-#
 
 type DeleteCompanyPayload {
   clientMutationId: String
   company: Company!
 }
-#
 
 type CompanyViewerPermission {
   canCreate: Boolean!
   canUpdate: Boolean!
   canDelete: Boolean!
 }
-#
 
-type User {
+type User implements Node {
   id: ID!
   createdAt: Time!
   updatedAt: Time!
@@ -1235,7 +1239,6 @@ type User {
   tasks(after: Cursor, first: Int, before: Cursor, last: Int, filterBy: TaskFilter, orderBy: [TaskOrder!]): TaskConnection!
   viewerPermission: UserViewerPermission!
 }
-#
 
 type UserConnection {
   nodes: [User!]!
@@ -1243,13 +1246,11 @@ type UserConnection {
   pageInfo: PageInfo!
   totalCount: Int
 }
-#
 
 type UserEdge {
   node: User!
   cursor: Cursor!
 }
-#
 
 input UserFilter {
   not: UserFilter
@@ -1263,13 +1264,11 @@ input UserFilter {
   age: IntFilter
   company: CompanyFilter
 }
-#
 
 input UserOrder {
   field: UserOrderField!
   direction: OrderDirection!
 }
-#
 
 enum UserOrderField {
   ID
@@ -1279,7 +1278,6 @@ enum UserOrderField {
   DESCRIPTION
   AGE
 }
-#
 
 input CreateUserInput {
   clientMutationId: String
@@ -1288,13 +1286,11 @@ input CreateUserInput {
   age: Int!
   companyId: ID!
 }
-#
 
 type CreateUserPayload {
   clientMutationId: String
   user: User!
 }
-#
 
 input UpdateUserInput {
   clientMutationId: String
@@ -1304,41 +1300,35 @@ input UpdateUserInput {
   age: Int
   companyId: ID
 }
-#
 
 type UpdateUserPayload {
   clientMutationId: String
   user: User!
 }
-#
 
 input DeleteUserInput {
   clientMutationId: String
   userId: ID!
 }
-#
 
 type DeleteUserPayload {
   clientMutationId: String
   user: User!
 }
-#
 
 type UserViewerPermission {
   canCreate: Boolean!
   canUpdate: Boolean!
   canDelete: Boolean!
 }
-#
 
 enum TaskStatus {
   OPEN
   IN_PROGRESS
   DONE
 }
-#
 
-type Task {
+type Task implements Node {
   id: ID!
   createdAt: Time!
   updatedAt: Time!
@@ -1348,7 +1338,6 @@ type Task {
   assignee: User
   viewerPermission: TaskViewerPermission!
 }
-#
 
 type TaskConnection {
   nodes: [Task!]!
@@ -1356,13 +1345,11 @@ type TaskConnection {
   pageInfo: PageInfo!
   totalCount: Int
 }
-#
 
 type TaskEdge {
   node: Task!
   cursor: Cursor!
 }
-#
 
 input TaskFilter {
   not: TaskFilter
@@ -1376,13 +1363,11 @@ input TaskFilter {
   status: EnumFilter
   assignee: UserFilter
 }
-#
 
 input TaskOrder {
   field: TaskOrderField!
   direction: OrderDirection!
 }
-#
 
 enum TaskOrderField {
   ID
@@ -1392,7 +1377,6 @@ enum TaskOrderField {
   DESCRIPTION
   STATUS
 }
-#
 
 input CreateTaskInput {
   clientMutationId: String
@@ -1401,13 +1385,11 @@ input CreateTaskInput {
   status: TaskStatus!
   assigneeId: ID
 }
-#
 
 type CreateTaskPayload {
   clientMutationId: String
   task: Task!
 }
-#
 
 input UpdateTaskInput {
   clientMutationId: String
@@ -1417,67 +1399,62 @@ input UpdateTaskInput {
   status: TaskStatus
   assigneeId: ID
 }
-#
 
 type UpdateTaskPayload {
   clientMutationId: String
   task: Task!
 }
-#
 
 input DeleteTaskInput {
   clientMutationId: String
   taskId: ID!
 }
-#
 
 type DeleteTaskPayload {
   clientMutationId: String
   task: Task!
 }
-#
 
 type TaskViewerPermission {
   canCreate: Boolean!
   canUpdate: Boolean!
   canDelete: Boolean!
 }
-#
 
 extend type Query {
   companies(after: Cursor, first: Int, before: Cursor, last: Int, filterBy: CompanyFilter, orderBy: [CompanyOrder!]): CompanyConnection!
 }
-#
 
 extend type Mutation {
   createCompany(input: CreateCompanyInput!): CreateCompanyPayload!
   updateCompany(input: UpdateCompanyInput!): UpdateCompanyPayload!
   deleteCompany(input: DeleteCompanyInput!): DeleteCompanyPayload!
 }
-#
 
 extend type Query {
   users(after: Cursor, first: Int, before: Cursor, last: Int, filterBy: UserFilter, orderBy: [UserOrder!]): UserConnection!
 }
-#
 
 extend type Mutation {
   createUser(input: CreateUserInput!): CreateUserPayload!
   updateUser(input: UpdateUserInput!): UpdateUserPayload!
   deleteUser(input: DeleteUserInput!): DeleteUserPayload!
 }
-#
 
 extend type Query {
   tasks(after: Cursor, first: Int, before: Cursor, last: Int, filterBy: TaskFilter, orderBy: [TaskOrder!]): TaskConnection!
 }
-#
 
 extend type Mutation {
   createTask(input: CreateTaskInput!): CreateTaskPayload!
   updateTask(input: UpdateTaskInput!): UpdateTaskPayload!
   deleteTask(input: DeleteTaskInput!): DeleteTaskPayload!
 }
+
+extend type Query {
+  node(id: ID!): Node
+  nodes(ids: [ID!]!): [Node]!
+}
 `, BuiltIn: false},
 }
 var parsedSchema = gqlparser.MustLoadSchema(sources...)
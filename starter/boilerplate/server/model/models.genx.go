@@ -22,6 +22,9 @@ type Company struct {
 	Description *string        `json:"description,omitempty"`
 }
 
+func (Company) IsNode()            {}
+func (this Company) GetID() string { return this.ID }
+
 type (
 	CompanyEdge       = relay.Edge[*Company]
 	CompanyConnection = relay.Connection[*Company]
@@ -38,6 +41,9 @@ type Task struct {
 	AssigneeID  *string        `json:"assigneeId,omitempty"`
 }
 
+func (Task) IsNode()            {}
+func (this Task) GetID() string { return this.ID }
+
 type (
 	TaskEdge       = relay.Edge[*Task]
 	TaskConnection = relay.Connection[*Task]
@@ -54,6 +60,9 @@ type User struct {
 	CompanyID   string         `gorm:"not null" json:"companyId"`
 }
 
+func (User) IsNode()            {}
+func (this User) GetID() string { return this.ID }
+
 type (
 	UserEdge       = relay.Edge[*User]
 	UserConnection = relay.Connection[*User]
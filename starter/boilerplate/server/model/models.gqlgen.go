@@ -6,12 +6,18 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"time"
 )
 
+type Node interface {
+	IsNode()
+	GetID() string
+}
+
 type BooleanFilter struct {
-	Equals *string `json:"equals,omitempty"`
-	Not    *string `json:"not,omitempty"`
-	IsNull *bool   `json:"isNull,omitempty"`
+	Equals *bool `json:"equals,omitempty"`
+	Not    *bool `json:"not,omitempty"`
+	IsNull *bool `json:"isNull,omitempty"`
 }
 
 type CompanyFilter struct {
@@ -112,15 +118,15 @@ type EnumFilter struct {
 }
 
 type FloatFilter struct {
-	Equals *string  `json:"equals,omitempty"`
-	Not    *string  `json:"not,omitempty"`
-	In     []string `json:"in,omitempty"`
-	NotIn  []string `json:"notIn,omitempty"`
-	Lt     *string  `json:"lt,omitempty"`
-	Lte    *string  `json:"lte,omitempty"`
-	Gt     *string  `json:"gt,omitempty"`
-	Gte    *string  `json:"gte,omitempty"`
-	IsNull *bool    `json:"isNull,omitempty"`
+	Equals *float64  `json:"equals,omitempty"`
+	Not    *float64  `json:"not,omitempty"`
+	In     []float64 `json:"in,omitempty"`
+	NotIn  []float64 `json:"notIn,omitempty"`
+	Lt     *float64  `json:"lt,omitempty"`
+	Lte    *float64  `json:"lte,omitempty"`
+	Gt     *float64  `json:"gt,omitempty"`
+	Gte    *float64  `json:"gte,omitempty"`
+	IsNull *bool     `json:"isNull,omitempty"`
 }
 
 type IDFilter struct {
@@ -140,15 +146,15 @@ type IDFilter struct {
 }
 
 type IntFilter struct {
-	Equals *string  `json:"equals,omitempty"`
-	Not    *string  `json:"not,omitempty"`
-	In     []string `json:"in,omitempty"`
-	NotIn  []string `json:"notIn,omitempty"`
-	Lt     *string  `json:"lt,omitempty"`
-	Lte    *string  `json:"lte,omitempty"`
-	Gt     *string  `json:"gt,omitempty"`
-	Gte    *string  `json:"gte,omitempty"`
-	IsNull *bool    `json:"isNull,omitempty"`
+	Equals *int  `json:"equals,omitempty"`
+	Not    *int  `json:"not,omitempty"`
+	In     []int `json:"in,omitempty"`
+	NotIn  []int `json:"notIn,omitempty"`
+	Lt     *int  `json:"lt,omitempty"`
+	Lte    *int  `json:"lte,omitempty"`
+	Gt     *int  `json:"gt,omitempty"`
+	Gte    *int  `json:"gte,omitempty"`
+	IsNull *bool `json:"isNull,omitempty"`
 }
 
 type Mutation struct {
@@ -198,15 +204,15 @@ type TaskViewerPermission struct {
 }
 
 type TimeFilter struct {
-	Equals *string  `json:"equals,omitempty"`
-	Not    *string  `json:"not,omitempty"`
-	In     []string `json:"in,omitempty"`
-	NotIn  []string `json:"notIn,omitempty"`
-	Lt     *string  `json:"lt,omitempty"`
-	Lte    *string  `json:"lte,omitempty"`
-	Gt     *string  `json:"gt,omitempty"`
-	Gte    *string  `json:"gte,omitempty"`
-	IsNull *bool    `json:"isNull,omitempty"`
+	Equals *time.Time   `json:"equals,omitempty"`
+	Not    *time.Time   `json:"not,omitempty"`
+	In     []*time.Time `json:"in,omitempty"`
+	NotIn  []*time.Time `json:"notIn,omitempty"`
+	Lt     *time.Time   `json:"lt,omitempty"`
+	Lte    *time.Time   `json:"lte,omitempty"`
+	Gt     *time.Time   `json:"gt,omitempty"`
+	Gte    *time.Time   `json:"gte,omitempty"`
+	IsNull *bool        `json:"isNull,omitempty"`
 }
 
 type UpdateCompanyInput struct {
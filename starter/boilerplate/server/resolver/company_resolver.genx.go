@@ -12,9 +12,9 @@ import (
 	"github.com/samber/lo"
 	"github.com/theplant/relay"
 	"github.com/theplant/relay/cursor"
-	"github.com/theplant/relay/gormrelay"
 	"github.com/vikstrous/dataloadgen"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CompanyResolver struct {
@@ -32,7 +32,10 @@ func (c *CompanyResolver) initPagination() {
 	c.pagination = relay.New(
 		cursor.Base64(func(ctx context.Context, req *relay.ApplyCursorsRequest) (*relay.ApplyCursorsResponse[*model.Company], error) {
 			// TODO: 做一下 select 处理？并且对于 keyset 的情况一定要包含最终的 order by 字段
-			return gormrelay.NewKeysetAdapter[*model.Company](c.DB(ctx))(ctx, req)
+			return cursor.NewKeysetAdapter[*model.Company](&keysetFinder[*model.Company]{
+				db:     filtered(ctx, c.DB(ctx)).Model(&model.Company{}).Session(&gorm.Session{}),
+				loader: c.Resolver.Loader(ctx).CompanyPartitions,
+			})(ctx, req)
 		}),
 		relay.EnsureLimits[*model.Company](100, 10),
 		relay.EnsurePrimaryOrderBy[*model.Company](
@@ -73,6 +76,14 @@ func (c *CompanyResolver) NewLoader() *dataloadgen.Loader[string, *model.Company
 	)
 }
 
+// NewPartitionLoader batches the pages of the connection fields of the nodes referencing companies.
+func (c *CompanyResolver) NewPartitionLoader() *PartitionLoader[*model.Company] {
+	return newPartitionLoader[*model.Company](
+		dataloadgen.WithBatchCapacity(100),
+		dataloadgen.WithWait(5*time.Millisecond),
+	)
+}
+
 func (c *CompanyResolver) Loader(ctx context.Context) *dataloadgen.Loader[string, *model.Company] {
 	return c.Resolver.Loader(ctx).Company
 }
@@ -84,10 +95,69 @@ func (c *CompanyResolver) Get(ctx context.Context, id *string) (*model.Company,
 	return c.Loader(ctx).Load(ctx, *id)
 }
 
-func (c *CompanyResolver) List(ctx context.Context, after *string, first *int, before *string, last *int, _ *model.CompanyFilter, orderBy []*model.CompanyOrder) (*model.CompanyConnection, error) {
+// filter translates filterBy into the conditions on companies, the filters of related nodes become subqueries.
+func (c *CompanyResolver) filter(ctx context.Context, filterBy *model.CompanyFilter) (clause.Expression, error) {
+	if filterBy == nil {
+		return nil, nil
+	}
+
+	db := c.DB(ctx)
+	column, err := filterColumns(db, &model.Company{})
+	if err != nil {
+		return nil, err
+	}
+
+	var exprs []clause.Expression
+	if filterBy.Not != nil {
+		expr, err := c.filter(ctx, filterBy.Not)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, filterNot(expr))
+	}
+	for _, and := range filterBy.And {
+		expr, err := c.filter(ctx, and)
+		if err != nil {
+			return nil, err
+		}
+		if expr != nil {
+			exprs = append(exprs, expr)
+		}
+	}
+	if filterBy.Or != nil {
+		ors := make([]clause.Expression, 0, len(filterBy.Or))
+		for _, or := range filterBy.Or {
+			expr, err := c.filter(ctx, or)
+			if err != nil {
+				return nil, err
+			}
+			ors = append(ors, expr)
+		}
+		if expr := filterOr(ors); expr != nil {
+			exprs = append(exprs, expr)
+		}
+	}
+	exprs = append(exprs, idFilterOps(filterBy.ID).exprs(column("ID"))...)
+	exprs = append(exprs, timeFilterOps(filterBy.CreatedAt).exprs(column("CreatedAt"))...)
+	exprs = append(exprs, timeFilterOps(filterBy.UpdatedAt).exprs(column("UpdatedAt"))...)
+	exprs = append(exprs, stringFilterOps(filterBy.Name).exprs(column("Name"))...)
+	exprs = append(exprs, stringFilterOps(filterBy.Description).exprs(column("Description"))...)
+	return filterAnd(exprs), nil
+}
+
+func (c *CompanyResolver) List(ctx context.Context, after *string, first *int, before *string, last *int, filterBy *model.CompanyFilter, orderBy []*model.CompanyOrder) (*model.CompanyConnection, error) {
+	return c.list(ctx, nil, after, first, before, last, filterBy, orderBy)
+}
+
+// list paginates the companies matching filterBy within scope, nil scope matches everything.
+func (c *CompanyResolver) list(ctx context.Context, scope clause.Expression, after *string, first *int, before *string, last *int, filterBy *model.CompanyFilter, orderBy []*model.CompanyOrder) (*model.CompanyConnection, error) {
+	expr, err := c.filter(ctx, filterBy)
+	if err != nil {
+		return nil, err
+	}
 	return c.pagination.Paginate(
 		relay.WithNodeProcessor(
-			gqlx.WithSkippedConnection(ctx),
+			withFilter(gqlx.WithSkippedConnection(ctx), filterAnd([]clause.Expression{scope, expr})),
 			func(node *model.Company) *model.Company {
 				// TODO: 如果某 id 对应的已经在 cache 里了，那之前从 cache 里取出来的数据使用的地方貌似不一定会和这里一致吧。貌似应该让 dataloader 支持先直接取 cache 。
 				c.Loader(ctx).Prime(node.ID, node)
@@ -107,16 +177,23 @@ func (c *CompanyResolver) List(ctx context.Context, after *string, first *int, b
 }
 
 func (c *CompanyResolver) Employees(ctx context.Context, company *model.Company, after *string, first *int, before *string, last *int, filterBy *model.UserFilter, orderBy []*model.UserOrder) (*relay.Connection[*model.User], error) {
-	// TODO: Need to cooperate with the corresponding one to one
-	// filterBy.Company = &model.CompanyFilter{
-	// 	ID: &model.IDFilter{Equals: &company.ID},
-	// }
-	return c.Resolver.User.List(ctx, after, first, before, last, filterBy, orderBy)
+	column, err := filterColumns(c.DB(ctx), &model.User{})
+	if err != nil {
+		return nil, err
+	}
+	ctx = withPartition(ctx, &partition[*model.User]{
+		column: column("CompanyID"),
+		owner:  company.ID,
+		ownerOf: func(user *model.User) string {
+			return user.CompanyID
+		},
+	})
+	return c.Resolver.User.list(ctx, nil, after, first, before, last, filterBy, orderBy)
 }
 
 func (c *CompanyResolver) new(_ context.Context, input model.CreateCompanyInput) *model.Company {
 	return &model.Company{
-		ID:          generateID(),
+		ID:          globalID("Company", generateID()),
 		Name:        input.Name,
 		Description: input.Description,
 	}
@@ -5,15 +5,26 @@ package resolver
 import (
 	"context"
 	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/molon/genx/pkg/gqlx"
 	"github.com/molon/genx/starter/boilerplate/server/model"
 	"github.com/pkg/errors"
 	"github.com/rs/xid"
+	"github.com/samber/lo"
+	"github.com/theplant/relay"
+	"github.com/theplant/relay/gormrelay"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vikstrous/dataloadgen"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
 )
 
 type Resolver struct {
@@ -32,24 +43,32 @@ func New(db *gorm.DB) *Resolver {
 }
 
 type Loader struct {
-	Company *dataloadgen.Loader[string, *model.Company]
-	Task    *dataloadgen.Loader[string, *model.Task]
-	User    *dataloadgen.Loader[string, *model.User]
+	Company           *dataloadgen.Loader[string, *model.Company]
+	Task              *dataloadgen.Loader[string, *model.Task]
+	User              *dataloadgen.Loader[string, *model.User]
+	CompanyPartitions *PartitionLoader[*model.Company]
+	TaskPartitions    *PartitionLoader[*model.Task]
+	UserPartitions    *PartitionLoader[*model.User]
 }
 
 type (
-	ctxKeyDB     struct{}
-	ctxKeyTx     struct{}
-	ctxKeyLoader struct{}
+	ctxKeyDB        struct{}
+	ctxKeyTx        struct{}
+	ctxKeyLoader    struct{}
+	ctxKeyFilter    struct{}
+	ctxKeyPartition struct{}
 )
 
 func (r *Resolver) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		// TODO: loader 放到这是不是不太合适呢，因为一个请求可能有多个 query 和 mutation ，对于 query 的话返回值相同可以接受，那么对于 mutation 的话呢？
 		loader := &Loader{
-			Company: r.Company.NewLoader(),
-			Task:    r.Task.NewLoader(),
-			User:    r.User.NewLoader(),
+			Company:           r.Company.NewLoader(),
+			Task:              r.Task.NewLoader(),
+			User:              r.User.NewLoader(),
+			CompanyPartitions: r.Company.NewPartitionLoader(),
+			TaskPartitions:    r.Task.NewPartitionLoader(),
+			UserPartitions:    r.User.NewPartitionLoader(),
 		}
 		ctx := context.WithValue(req.Context(), ctxKeyLoader{}, loader)
 		// TODO: 如果是 mutaion 的话，这一句实际上没啥意义了，所以事务那段可以在这里搞吗？
@@ -92,3 +111,573 @@ func (r *Resolver) OpenTx(ctx context.Context, op *ast.OperationDefinition) (con
 func generateID() string {
 	return xid.New().String()
 }
+
+// globalID encodes the type and id as the opaque base64 of Type:id.
+func globalID(typ string, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(typ + ":" + id))
+}
+
+// parseGlobalID returns the type encoded in the global id.
+func parseGlobalID(id string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid id %s", id)
+	}
+	typ, _, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return "", errors.Errorf("invalid id %s", id)
+	}
+	return typ, nil
+}
+
+func (r *Resolver) Node(ctx context.Context, id string) (model.Node, error) {
+	return r.loadNode(ctx, id)()
+}
+
+func (r *Resolver) Nodes(ctx context.Context, ids []string) ([]model.Node, error) {
+	thunks := lo.Map(ids, func(id string, _ int) func() (model.Node, error) {
+		return r.loadNode(ctx, id)
+	})
+	nodes := make([]model.Node, len(ids))
+	for i, thunk := range thunks {
+		node, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+// loadNode loads the node of the global id with the loader of its type, the nodes of a type are batched until the
+// returned thunk is called. Unknown types load nil.
+func (r *Resolver) loadNode(ctx context.Context, id string) func() (model.Node, error) {
+	typ, err := parseGlobalID(id)
+	if err != nil {
+		return func() (model.Node, error) { return nil, err }
+	}
+	switch typ {
+	case "Company":
+		thunk := r.Company.Loader(ctx).LoadThunk(ctx, id)
+		return func() (model.Node, error) {
+			company, err := thunk()
+			if company == nil || err != nil {
+				return nil, err
+			}
+			return company, nil
+		}
+	case "Task":
+		thunk := r.Task.Loader(ctx).LoadThunk(ctx, id)
+		return func() (model.Node, error) {
+			task, err := thunk()
+			if task == nil || err != nil {
+				return nil, err
+			}
+			return task, nil
+		}
+	case "User":
+		thunk := r.User.Loader(ctx).LoadThunk(ctx, id)
+		return func() (model.Node, error) {
+			user, err := thunk()
+			if user == nil || err != nil {
+				return nil, err
+			}
+			return user, nil
+		}
+	}
+	return func() (model.Node, error) { return nil, nil }
+}
+
+// withFilter scopes the pagination queries run with ctx to the conditions of expr, nil matches everything.
+func withFilter(ctx context.Context, expr clause.Expression) context.Context {
+	return context.WithValue(ctx, ctxKeyFilter{}, expr)
+}
+
+// filtered applies the conditions of withFilter to db.
+func filtered(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if expr, _ := ctx.Value(ctxKeyFilter{}).(clause.Expression); expr != nil {
+		return db.Where(expr)
+	}
+	return db
+}
+
+// filterOps are the operators of the scalar filters, T is the type of their operands.
+type filterOps[T any] struct {
+	Equals, Not, Lt, Lte, Gt, Gte  *T
+	In, NotIn                      []T
+	Contains, StartsWith, EndsWith *string
+	IsNull, Fold                   *bool
+}
+
+func booleanFilterOps(f *model.BooleanFilter) *filterOps[bool] {
+	if f == nil {
+		return nil
+	}
+	return &filterOps[bool]{
+		Equals: f.Equals,
+		Not:    f.Not,
+		IsNull: f.IsNull,
+	}
+}
+
+func enumFilterOps(f *model.EnumFilter) *filterOps[string] {
+	if f == nil {
+		return nil
+	}
+	return &filterOps[string]{
+		Equals: f.Equals,
+		Not:    f.Not,
+		In:     f.In,
+		NotIn:  f.NotIn,
+		IsNull: f.IsNull,
+	}
+}
+
+func floatFilterOps(f *model.FloatFilter) *filterOps[float64] {
+	if f == nil {
+		return nil
+	}
+	return &filterOps[float64]{
+		Equals: f.Equals,
+		Not:    f.Not,
+		In:     f.In,
+		NotIn:  f.NotIn,
+		Lt:     f.Lt,
+		Lte:    f.Lte,
+		Gt:     f.Gt,
+		Gte:    f.Gte,
+		IsNull: f.IsNull,
+	}
+}
+
+func idFilterOps(f *model.IDFilter) *filterOps[string] {
+	if f == nil {
+		return nil
+	}
+	return &filterOps[string]{
+		Equals:     f.Equals,
+		Not:        f.Not,
+		In:         f.In,
+		NotIn:      f.NotIn,
+		Lt:         f.Lt,
+		Lte:        f.Lte,
+		Gt:         f.Gt,
+		Gte:        f.Gte,
+		Contains:   f.Contains,
+		StartsWith: f.StartsWith,
+		EndsWith:   f.EndsWith,
+		IsNull:     f.IsNull,
+		Fold:       f.Fold,
+	}
+}
+
+func intFilterOps(f *model.IntFilter) *filterOps[int] {
+	if f == nil {
+		return nil
+	}
+	return &filterOps[int]{
+		Equals: f.Equals,
+		Not:    f.Not,
+		In:     f.In,
+		NotIn:  f.NotIn,
+		Lt:     f.Lt,
+		Lte:    f.Lte,
+		Gt:     f.Gt,
+		Gte:    f.Gte,
+		IsNull: f.IsNull,
+	}
+}
+
+func stringFilterOps(f *model.StringFilter) *filterOps[string] {
+	if f == nil {
+		return nil
+	}
+	return &filterOps[string]{
+		Equals:     f.Equals,
+		Not:        f.Not,
+		In:         f.In,
+		NotIn:      f.NotIn,
+		Lt:         f.Lt,
+		Lte:        f.Lte,
+		Gt:         f.Gt,
+		Gte:        f.Gte,
+		Contains:   f.Contains,
+		StartsWith: f.StartsWith,
+		EndsWith:   f.EndsWith,
+		IsNull:     f.IsNull,
+		Fold:       f.Fold,
+	}
+}
+
+func timeFilterOps(f *model.TimeFilter) *filterOps[time.Time] {
+	if f == nil {
+		return nil
+	}
+	return &filterOps[time.Time]{
+		Equals: f.Equals,
+		Not:    f.Not,
+		In:     derefs(f.In),
+		NotIn:  derefs(f.NotIn),
+		Lt:     f.Lt,
+		Lte:    f.Lte,
+		Gt:     f.Gt,
+		Gte:    f.Gte,
+		IsNull: f.IsNull,
+	}
+}
+
+// derefs dereferences the operands of a list operator, nil stays nil since an empty list matches nothing.
+func derefs[T any](vs []*T) []T {
+	if vs == nil {
+		return nil
+	}
+	return lo.FromSlicePtr(vs)
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// exprs translates the operators into conditions on column, fold compares lowercased values.
+func (o *filterOps[T]) exprs(column clause.Column) []clause.Expression {
+	if o == nil {
+		return nil
+	}
+
+	var exprs []clause.Expression
+	if o.IsNull != nil {
+		if *o.IsNull {
+			exprs = append(exprs, clause.Expr{SQL: "? IS NULL", Vars: []any{column}})
+		} else {
+			exprs = append(exprs, clause.Expr{SQL: "? IS NOT NULL", Vars: []any{column}})
+		}
+	}
+
+	var target any = column
+	fold := func(v string) string { return v }
+	if o.Fold != nil && *o.Fold {
+		target = clause.Expr{SQL: "LOWER(?)", Vars: []any{column}}
+		fold = strings.ToLower
+	}
+	// only the operands of the string filters are folded
+	value := func(v T) any {
+		if s, ok := any(v).(string); ok {
+			return fold(s)
+		}
+		return v
+	}
+	compare := func(op string, v any) {
+		exprs = append(exprs, clause.Expr{SQL: "? " + op + " ?", Vars: []any{target, v}})
+	}
+	for _, c := range []struct {
+		op string
+		v  *T
+	}{
+		{"=", o.Equals}, {"<>", o.Not}, {"<", o.Lt}, {"<=", o.Lte}, {">", o.Gt}, {">=", o.Gte},
+	} {
+		if c.v != nil {
+			compare(c.op, value(*c.v))
+		}
+	}
+	if o.In != nil {
+		if len(o.In) == 0 {
+			exprs = append(exprs, filterNone)
+		} else {
+			compare("IN", lo.Map(o.In, func(v T, _ int) any { return value(v) }))
+		}
+	}
+	if len(o.NotIn) > 0 {
+		compare("NOT IN", lo.Map(o.NotIn, func(v T, _ int) any { return value(v) }))
+	}
+	for _, c := range []struct {
+		prefix, suffix string
+		v              *string
+	}{
+		{"%", "%", o.Contains}, {"", "%", o.StartsWith}, {"%", "", o.EndsWith},
+	} {
+		if c.v != nil {
+			exprs = append(exprs, clause.Expr{
+				SQL:  "? LIKE ? ESCAPE '!'",
+				Vars: []any{target, c.prefix + likeEscaper.Replace(fold(*c.v)) + c.suffix},
+			})
+		}
+	}
+	return exprs
+}
+
+// filterNone matches nothing.
+var filterNone = clause.Expr{SQL: "1 = 0"}
+
+// filterAnd matches the rows matching all exprs, nil matches everything.
+func filterAnd(exprs []clause.Expression) clause.Expression {
+	return filterJoin(lo.Compact(exprs), " AND ")
+}
+
+// filterOr matches the rows matching any of exprs, a nil expression matches everything.
+func filterOr(exprs []clause.Expression) clause.Expression {
+	if len(exprs) == 0 {
+		return filterNone
+	}
+	if lo.Contains(exprs, nil) {
+		return nil
+	}
+	return filterJoin(exprs, " OR ")
+}
+
+// filterNot negates expr, the negation of nil matches nothing.
+func filterNot(expr clause.Expression) clause.Expression {
+	if expr == nil {
+		return filterNone
+	}
+	return clause.Expr{SQL: "NOT (?)", Vars: []any{expr}}
+}
+
+func filterJoin(exprs []clause.Expression, sep string) clause.Expression {
+	switch len(exprs) {
+	case 0:
+		return nil
+	case 1:
+		return exprs[0]
+	}
+	return clause.Expr{
+		SQL:  "(" + strings.Repeat("?"+sep, len(exprs)-1) + "?)",
+		Vars: lo.ToAnySlice(exprs),
+	}
+}
+
+// filterIn matches the rows whose column is selected by the subquery.
+func filterIn(column clause.Column, subquery *gorm.DB) clause.Expression {
+	return clause.Expr{SQL: "? IN (?)", Vars: []any{column, subquery}}
+}
+
+// filterColumns resolves the columns of the fields of the model.
+func filterColumns(db *gorm.DB, model any) (func(field string) clause.Column, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, errors.Wrap(err, "failed to parse model")
+	}
+	return func(field string) clause.Column {
+		name := field
+		if f := stmt.Schema.LookUpField(field); f != nil {
+			name = f.DBName
+		}
+		return clause.Column{Table: clause.CurrentTable, Name: name}
+	}, nil
+}
+
+// partition pages the nodes of owner by column, the pages of the owners sharing their query are loaded together.
+type partition[T any] struct {
+	column  clause.Column
+	owner   string
+	ownerOf func(node T) string
+}
+
+// withPartition batches the pagination queries run with ctx by the partition p.
+func withPartition[T any](ctx context.Context, p *partition[T]) context.Context {
+	return context.WithValue(ctx, ctxKeyPartition{}, p)
+}
+
+// partitionKey is the page or count of owner in a query.
+type partitionKey struct {
+	query string
+	owner string
+}
+
+// partitionQuery is a query shared by the partitions of a batch, limited per partition with a window function.
+type partitionQuery[T any] struct {
+	db      *gorm.DB
+	column  clause.Column
+	ownerOf func(node T) string
+	orderBy clause.OrderBy
+	limit   int
+	fromEnd bool
+}
+
+// PartitionLoader loads the pages and counts of the partitions of a node type, one query per batch of partitions
+// sharing their query.
+type PartitionLoader[T any] struct {
+	mu      sync.Mutex
+	queries map[string]*partitionQuery[T]
+	pages   *dataloadgen.Loader[partitionKey, []T]
+	counts  *dataloadgen.Loader[partitionKey, int]
+}
+
+type partitionCount struct {
+	Owner string
+	Count int
+}
+
+func newPartitionLoader[T any](options ...dataloadgen.Option) *PartitionLoader[T] {
+	l := &PartitionLoader[T]{queries: map[string]*partitionQuery[T]{}}
+	l.pages = dataloadgen.NewLoader(l.batchPages, options...)
+	l.counts = dataloadgen.NewLoader(l.batchCounts, options...)
+	return l
+}
+
+// register identifies q by the statement of tx, which has run in dry run mode.
+func (l *PartitionLoader[T]) register(q *partitionQuery[T], tx *gorm.DB) string {
+	query := fmt.Sprintf("%s: %s %v", q.column.Name, tx.Statement.SQL.String(), tx.Statement.Vars)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.queries[query]; !ok {
+		l.queries[query] = q
+	}
+	return query
+}
+
+// group groups the indexes of keys by their queries.
+func (l *PartitionLoader[T]) group(keys []partitionKey) map[*partitionQuery[T]][]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	groups := map[*partitionQuery[T]][]int{}
+	for i, key := range keys {
+		q := l.queries[key.query]
+		groups[q] = append(groups[q], i)
+	}
+	return groups
+}
+
+func (l *PartitionLoader[T]) batchPages(_ context.Context, keys []partitionKey) ([][]T, []error) {
+	pages := make([][]T, len(keys))
+	errs := make([]error, len(keys))
+	for q, indexes := range l.group(keys) {
+		owners := lo.Uniq(lo.Map(indexes, func(i int, _ int) string { return keys[i].owner }))
+		// q.orderBy is a clause, which is written with its ORDER BY keyword
+		rows := q.db.Select(
+			"?.*, ROW_NUMBER() OVER (PARTITION BY ? ?) AS partition_row",
+			clause.Table{Name: clause.CurrentTable}, q.column, q.orderBy,
+		).Where(clause.IN{Column: q.column, Values: lo.ToAnySlice(owners)})
+
+		var nodes []T
+		if err := q.db.Session(&gorm.Session{NewDB: true}).Table("(?) AS partition_rows", rows).
+			Where("partition_row <= ?", q.limit).Order("partition_row").
+			Find(&nodes).Error; err != nil {
+			for _, i := range indexes {
+				errs[i] = errors.Wrap(err, "failed to find partitions")
+			}
+			continue
+		}
+
+		ownerToNodes := lo.GroupBy(nodes, q.ownerOf)
+		for _, i := range indexes {
+			page := slices.Clone(ownerToNodes[keys[i].owner])
+			if q.fromEnd {
+				slices.Reverse(page)
+			}
+			pages[i] = page
+		}
+	}
+	return pages, errs
+}
+
+func (l *PartitionLoader[T]) batchCounts(_ context.Context, keys []partitionKey) ([]int, []error) {
+	counts := make([]int, len(keys))
+	errs := make([]error, len(keys))
+	for q, indexes := range l.group(keys) {
+		owners := lo.Uniq(lo.Map(indexes, func(i int, _ int) string { return keys[i].owner }))
+		var rows []*partitionCount
+		if err := q.db.Select("? AS owner, COUNT(*) AS count", q.column).
+			Where(clause.IN{Column: q.column, Values: lo.ToAnySlice(owners)}).
+			Clauses(clause.GroupBy{Columns: []clause.Column{q.column}}).
+			Scan(&rows).Error; err != nil {
+			for _, i := range indexes {
+				errs[i] = errors.Wrap(err, "failed to count partitions")
+			}
+			continue
+		}
+
+		ownerToCount := lo.SliceToMap(rows, func(row *partitionCount) (string, int) {
+			return row.Owner, row.Count
+		})
+		for _, i := range indexes {
+			counts[i] = ownerToCount[keys[i].owner]
+		}
+	}
+	return counts, errs
+}
+
+// keysetFinder finds the nodes of db for the keyset pagination, batched by the partition of the context if any.
+type keysetFinder[T any] struct {
+	db     *gorm.DB
+	loader *PartitionLoader[T]
+}
+
+func (f *keysetFinder[T]) Find(ctx context.Context, after, before *map[string]any, orderBys []relay.OrderBy, limit int, fromEnd bool) ([]T, error) {
+	p, _ := ctx.Value(ctxKeyPartition{}).(*partition[T])
+	if p == nil {
+		return gormrelay.NewKeysetFinder[T](f.db).Find(ctx, after, before, orderBys, limit, fromEnd)
+	}
+	if limit == 0 {
+		return []T{}, nil
+	}
+
+	column, err := filterColumns(f.db, f.db.Statement.Model)
+	if err != nil {
+		return nil, err
+	}
+	db := f.db
+	for _, keyset := range []struct {
+		values  *map[string]any
+		reverse bool
+	}{
+		{after, false}, {before, true},
+	} {
+		if keyset.values == nil {
+			continue
+		}
+		expr, err := keysetExpr(column, orderBys, *keyset.values, keyset.reverse)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(expr)
+	}
+	orderBy := clause.OrderBy{Columns: lo.Map(orderBys, func(orderBy relay.OrderBy, _ int) clause.OrderByColumn {
+		return clause.OrderByColumn{Column: column(orderBy.Field), Desc: orderBy.Desc != fromEnd}
+	})}
+
+	q := &partitionQuery[T]{
+		db:      db.Session(&gorm.Session{}),
+		column:  p.column,
+		ownerOf: p.ownerOf,
+		orderBy: orderBy,
+		limit:   limit,
+		fromEnd: fromEnd,
+	}
+	query := f.loader.register(q, dryRun(db).Clauses(orderBy).Limit(limit).Find(&[]T{}))
+	return f.loader.pages.Load(ctx, partitionKey{query: query, owner: p.owner})
+}
+
+func (f *keysetFinder[T]) Count(ctx context.Context) (int, error) {
+	p, _ := ctx.Value(ctxKeyPartition{}).(*partition[T])
+	if p == nil {
+		return gormrelay.NewKeysetFinder[T](f.db).Count(ctx)
+	}
+
+	q := &partitionQuery[T]{db: f.db, column: p.column}
+	query := f.loader.register(q, dryRun(f.db).Count(new(int64)))
+	return f.loader.counts.Load(ctx, partitionKey{query: query, owner: p.owner})
+}
+
+// dryRun builds the statements of db without running nor logging them.
+func dryRun(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{DryRun: true, Logger: logger.Discard})
+}
+
+// keysetExpr matches the nodes after keyset in the order of orderBys, or before it if reverse.
+func keysetExpr(column func(field string) clause.Column, orderBys []relay.OrderBy, keyset map[string]any, reverse bool) (clause.Expression, error) {
+	ors := make([]clause.Expression, 0, len(orderBys))
+	eqs := make([]clause.Expression, 0, len(orderBys))
+	for _, orderBy := range orderBys {
+		v, ok := keyset[orderBy.Field]
+		if !ok {
+			return nil, errors.Errorf("missing field %q in keyset", orderBy.Field)
+		}
+		c := column(orderBy.Field)
+		var expr clause.Expression = clause.Gt{Column: c, Value: v}
+		if orderBy.Desc != reverse {
+			expr = clause.Lt{Column: c, Value: v}
+		}
+		ors = append(ors, clause.And(append(slices.Clone(eqs), expr)...))
+		eqs = append(eqs, clause.Eq{Column: c, Value: v})
+	}
+	// a single OR condition would be joined with the other conditions of WHERE
+	return clause.And(clause.Or(ors...)), nil
+}
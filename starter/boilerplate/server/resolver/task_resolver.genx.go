@@ -12,9 +12,9 @@ import (
 	"github.com/samber/lo"
 	"github.com/theplant/relay"
 	"github.com/theplant/relay/cursor"
-	"github.com/theplant/relay/gormrelay"
 	"github.com/vikstrous/dataloadgen"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TaskResolver struct {
@@ -32,7 +32,10 @@ func (c *TaskResolver) initPagination() {
 	c.pagination = relay.New(
 		cursor.Base64(func(ctx context.Context, req *relay.ApplyCursorsRequest) (*relay.ApplyCursorsResponse[*model.Task], error) {
 			// TODO: 做一下 select 处理？并且对于 keyset 的情况一定要包含最终的 order by 字段
-			return gormrelay.NewKeysetAdapter[*model.Task](c.DB(ctx))(ctx, req)
+			return cursor.NewKeysetAdapter[*model.Task](&keysetFinder[*model.Task]{
+				db:     filtered(ctx, c.DB(ctx)).Model(&model.Task{}).Session(&gorm.Session{}),
+				loader: c.Resolver.Loader(ctx).TaskPartitions,
+			})(ctx, req)
 		}),
 		relay.EnsureLimits[*model.Task](100, 10),
 		relay.EnsurePrimaryOrderBy[*model.Task](
@@ -73,6 +76,14 @@ func (c *TaskResolver) NewLoader() *dataloadgen.Loader[string, *model.Task] {
 	)
 }
 
+// NewPartitionLoader batches the pages of the connection fields of the nodes referencing tasks.
+func (c *TaskResolver) NewPartitionLoader() *PartitionLoader[*model.Task] {
+	return newPartitionLoader[*model.Task](
+		dataloadgen.WithBatchCapacity(100),
+		dataloadgen.WithWait(5*time.Millisecond),
+	)
+}
+
 func (c *TaskResolver) Loader(ctx context.Context) *dataloadgen.Loader[string, *model.Task] {
 	return c.Resolver.Loader(ctx).Task
 }
@@ -84,10 +95,81 @@ func (c *TaskResolver) Get(ctx context.Context, id *string) (*model.Task, error)
 	return c.Loader(ctx).Load(ctx, *id)
 }
 
-func (c *TaskResolver) List(ctx context.Context, after *string, first *int, before *string, last *int, _ *model.TaskFilter, orderBy []*model.TaskOrder) (*model.TaskConnection, error) {
+// filter translates filterBy into the conditions on tasks, the filters of related nodes become subqueries.
+func (c *TaskResolver) filter(ctx context.Context, filterBy *model.TaskFilter) (clause.Expression, error) {
+	if filterBy == nil {
+		return nil, nil
+	}
+
+	db := c.DB(ctx)
+	column, err := filterColumns(db, &model.Task{})
+	if err != nil {
+		return nil, err
+	}
+
+	var exprs []clause.Expression
+	if filterBy.Not != nil {
+		expr, err := c.filter(ctx, filterBy.Not)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, filterNot(expr))
+	}
+	for _, and := range filterBy.And {
+		expr, err := c.filter(ctx, and)
+		if err != nil {
+			return nil, err
+		}
+		if expr != nil {
+			exprs = append(exprs, expr)
+		}
+	}
+	if filterBy.Or != nil {
+		ors := make([]clause.Expression, 0, len(filterBy.Or))
+		for _, or := range filterBy.Or {
+			expr, err := c.filter(ctx, or)
+			if err != nil {
+				return nil, err
+			}
+			ors = append(ors, expr)
+		}
+		if expr := filterOr(ors); expr != nil {
+			exprs = append(exprs, expr)
+		}
+	}
+	exprs = append(exprs, idFilterOps(filterBy.ID).exprs(column("ID"))...)
+	exprs = append(exprs, timeFilterOps(filterBy.CreatedAt).exprs(column("CreatedAt"))...)
+	exprs = append(exprs, timeFilterOps(filterBy.UpdatedAt).exprs(column("UpdatedAt"))...)
+	exprs = append(exprs, stringFilterOps(filterBy.Title).exprs(column("Title"))...)
+	exprs = append(exprs, stringFilterOps(filterBy.Description).exprs(column("Description"))...)
+	exprs = append(exprs, enumFilterOps(filterBy.Status).exprs(column("Status"))...)
+	if filterBy.Assignee != nil {
+		expr, err := c.Resolver.User.filter(ctx, filterBy.Assignee)
+		if err != nil {
+			return nil, err
+		}
+		subquery := db.Session(&gorm.Session{NewDB: true}).Model(&model.User{}).Select("id")
+		if expr != nil {
+			subquery = subquery.Where(expr)
+		}
+		exprs = append(exprs, filterIn(column("AssigneeID"), subquery))
+	}
+	return filterAnd(exprs), nil
+}
+
+func (c *TaskResolver) List(ctx context.Context, after *string, first *int, before *string, last *int, filterBy *model.TaskFilter, orderBy []*model.TaskOrder) (*model.TaskConnection, error) {
+	return c.list(ctx, nil, after, first, before, last, filterBy, orderBy)
+}
+
+// list paginates the tasks matching filterBy within scope, nil scope matches everything.
+func (c *TaskResolver) list(ctx context.Context, scope clause.Expression, after *string, first *int, before *string, last *int, filterBy *model.TaskFilter, orderBy []*model.TaskOrder) (*model.TaskConnection, error) {
+	expr, err := c.filter(ctx, filterBy)
+	if err != nil {
+		return nil, err
+	}
 	return c.pagination.Paginate(
 		relay.WithNodeProcessor(
-			gqlx.WithSkippedConnection(ctx),
+			withFilter(gqlx.WithSkippedConnection(ctx), filterAnd([]clause.Expression{scope, expr})),
 			func(node *model.Task) *model.Task {
 				// TODO: 如果某 id 对应的已经在 cache 里了，那之前从 cache 里取出来的数据使用的地方貌似不一定会和这里一致吧。貌似应该让 dataloader 支持先直接取 cache 。
 				c.Loader(ctx).Prime(node.ID, node)
@@ -112,7 +194,7 @@ func (c *TaskResolver) Assignee(ctx context.Context, task *model.Task) (*model.U
 
 func (c *TaskResolver) new(_ context.Context, input model.CreateTaskInput) *model.Task {
 	return &model.Task{
-		ID:          generateID(),
+		ID:          globalID("Task", generateID()),
 		Title:       input.Title,
 		Description: input.Description,
 		Status:      input.Status,
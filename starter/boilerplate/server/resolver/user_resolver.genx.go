@@ -12,9 +12,9 @@ import (
 	"github.com/samber/lo"
 	"github.com/theplant/relay"
 	"github.com/theplant/relay/cursor"
-	"github.com/theplant/relay/gormrelay"
 	"github.com/vikstrous/dataloadgen"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserResolver struct {
@@ -32,7 +32,10 @@ func (c *UserResolver) initPagination() {
 	c.pagination = relay.New(
 		cursor.Base64(func(ctx context.Context, req *relay.ApplyCursorsRequest) (*relay.ApplyCursorsResponse[*model.User], error) {
 			// TODO: 做一下 select 处理？并且对于 keyset 的情况一定要包含最终的 order by 字段
-			return gormrelay.NewKeysetAdapter[*model.User](c.DB(ctx))(ctx, req)
+			return cursor.NewKeysetAdapter[*model.User](&keysetFinder[*model.User]{
+				db:     filtered(ctx, c.DB(ctx)).Model(&model.User{}).Session(&gorm.Session{}),
+				loader: c.Resolver.Loader(ctx).UserPartitions,
+			})(ctx, req)
 		}),
 		relay.EnsureLimits[*model.User](100, 10),
 		relay.EnsurePrimaryOrderBy[*model.User](
@@ -73,6 +76,14 @@ func (c *UserResolver) NewLoader() *dataloadgen.Loader[string, *model.User] {
 	)
 }
 
+// NewPartitionLoader batches the pages of the connection fields of the nodes referencing users.
+func (c *UserResolver) NewPartitionLoader() *PartitionLoader[*model.User] {
+	return newPartitionLoader[*model.User](
+		dataloadgen.WithBatchCapacity(100),
+		dataloadgen.WithWait(5*time.Millisecond),
+	)
+}
+
 func (c *UserResolver) Loader(ctx context.Context) *dataloadgen.Loader[string, *model.User] {
 	return c.Resolver.Loader(ctx).User
 }
@@ -84,10 +95,81 @@ func (c *UserResolver) Get(ctx context.Context, id *string) (*model.User, error)
 	return c.Loader(ctx).Load(ctx, *id)
 }
 
-func (c *UserResolver) List(ctx context.Context, after *string, first *int, before *string, last *int, _ *model.UserFilter, orderBy []*model.UserOrder) (*model.UserConnection, error) {
+// filter translates filterBy into the conditions on users, the filters of related nodes become subqueries.
+func (c *UserResolver) filter(ctx context.Context, filterBy *model.UserFilter) (clause.Expression, error) {
+	if filterBy == nil {
+		return nil, nil
+	}
+
+	db := c.DB(ctx)
+	column, err := filterColumns(db, &model.User{})
+	if err != nil {
+		return nil, err
+	}
+
+	var exprs []clause.Expression
+	if filterBy.Not != nil {
+		expr, err := c.filter(ctx, filterBy.Not)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, filterNot(expr))
+	}
+	for _, and := range filterBy.And {
+		expr, err := c.filter(ctx, and)
+		if err != nil {
+			return nil, err
+		}
+		if expr != nil {
+			exprs = append(exprs, expr)
+		}
+	}
+	if filterBy.Or != nil {
+		ors := make([]clause.Expression, 0, len(filterBy.Or))
+		for _, or := range filterBy.Or {
+			expr, err := c.filter(ctx, or)
+			if err != nil {
+				return nil, err
+			}
+			ors = append(ors, expr)
+		}
+		if expr := filterOr(ors); expr != nil {
+			exprs = append(exprs, expr)
+		}
+	}
+	exprs = append(exprs, idFilterOps(filterBy.ID).exprs(column("ID"))...)
+	exprs = append(exprs, timeFilterOps(filterBy.CreatedAt).exprs(column("CreatedAt"))...)
+	exprs = append(exprs, timeFilterOps(filterBy.UpdatedAt).exprs(column("UpdatedAt"))...)
+	exprs = append(exprs, stringFilterOps(filterBy.Name).exprs(column("Name"))...)
+	exprs = append(exprs, stringFilterOps(filterBy.Description).exprs(column("Description"))...)
+	exprs = append(exprs, intFilterOps(filterBy.Age).exprs(column("Age"))...)
+	if filterBy.Company != nil {
+		expr, err := c.Resolver.Company.filter(ctx, filterBy.Company)
+		if err != nil {
+			return nil, err
+		}
+		subquery := db.Session(&gorm.Session{NewDB: true}).Model(&model.Company{}).Select("id")
+		if expr != nil {
+			subquery = subquery.Where(expr)
+		}
+		exprs = append(exprs, filterIn(column("CompanyID"), subquery))
+	}
+	return filterAnd(exprs), nil
+}
+
+func (c *UserResolver) List(ctx context.Context, after *string, first *int, before *string, last *int, filterBy *model.UserFilter, orderBy []*model.UserOrder) (*model.UserConnection, error) {
+	return c.list(ctx, nil, after, first, before, last, filterBy, orderBy)
+}
+
+// list paginates the users matching filterBy within scope, nil scope matches everything.
+func (c *UserResolver) list(ctx context.Context, scope clause.Expression, after *string, first *int, before *string, last *int, filterBy *model.UserFilter, orderBy []*model.UserOrder) (*model.UserConnection, error) {
+	expr, err := c.filter(ctx, filterBy)
+	if err != nil {
+		return nil, err
+	}
 	return c.pagination.Paginate(
 		relay.WithNodeProcessor(
-			gqlx.WithSkippedConnection(ctx),
+			withFilter(gqlx.WithSkippedConnection(ctx), filterAnd([]clause.Expression{scope, expr})),
 			func(node *model.User) *model.User {
 				// TODO: 如果某 id 对应的已经在 cache 里了，那之前从 cache 里取出来的数据使用的地方貌似不一定会和这里一致吧。貌似应该让 dataloader 支持先直接取 cache 。
 				c.Loader(ctx).Prime(node.ID, node)
@@ -111,16 +193,23 @@ func (c *UserResolver) Company(ctx context.Context, user *model.User) (*model.Co
 }
 
 func (c *UserResolver) Tasks(ctx context.Context, user *model.User, after *string, first *int, before *string, last *int, filterBy *model.TaskFilter, orderBy []*model.TaskOrder) (*relay.Connection[*model.Task], error) {
-	// TODO: Need to cooperate with the corresponding one to one
-	// filterBy.User = &model.UserFilter{
-	// 	ID: &model.IDFilter{Equals: &user.ID},
-	// }
-	return c.Resolver.Task.List(ctx, after, first, before, last, filterBy, orderBy)
+	column, err := filterColumns(c.DB(ctx), &model.Task{})
+	if err != nil {
+		return nil, err
+	}
+	ctx = withPartition(ctx, &partition[*model.Task]{
+		column: column("AssigneeID"),
+		owner:  user.ID,
+		ownerOf: func(task *model.Task) string {
+			return lo.FromPtr(task.AssigneeID)
+		},
+	})
+	return c.Resolver.Task.list(ctx, nil, after, first, before, last, filterBy, orderBy)
 }
 
 func (c *UserResolver) new(_ context.Context, input model.CreateUserInput) *model.User {
 	return &model.User{
-		ID:          generateID(),
+		ID:          globalID("User", generateID()),
 		Name:        input.Name,
 		Description: input.Description,
 		Age:         input.Age,
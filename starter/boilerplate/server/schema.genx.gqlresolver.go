@@ -86,6 +86,16 @@ func (r *queryGQLResolver) Tasks(ctx context.Context, after *string, first *int,
 	return r.Resolver.Task.List(ctx, after, first, before, last, filterBy, orderBy)
 }
 
+// Node is the resolver for the node field.
+func (r *queryGQLResolver) Node(ctx context.Context, id string) (model.Node, error) {
+	return r.Resolver.Node(ctx, id)
+}
+
+// Nodes is the resolver for the nodes field.
+func (r *queryGQLResolver) Nodes(ctx context.Context, ids []string) ([]model.Node, error) {
+	return r.Resolver.Nodes(ctx, ids)
+}
+
 // Assignee is the resolver for the assignee field.
 func (r *taskGQLResolver) Assignee(ctx context.Context, obj *model.Task) (*model.User, error) {
 	return r.Resolver.Task.Assignee(ctx, obj)
@@ -0,0 +1,111 @@
+package genx
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// GenerateTargets generates every config as a target of one run, e.g. the services of a monorepo. The targets run
+// concurrently and share a FormatCache, each one is committed on its own as soon as all its phases succeeded.
+// The options are applied to every config, so the extensions, which keep state during a run, belong into the configs.
+// The callbacks of the options are serialized. The diagnostics of all failed targets are returned as Diagnostics
+// tagged with their target.
+func GenerateTargets(ctx context.Context, configs []*Config, options ...Option) error {
+	if err := validateTargets(configs); err != nil {
+		return err
+	}
+	options = append([]Option{ShareFormatCache(NewFormatCache())}, options...)
+	options = append(options, serializeCallbacks(&sync.Mutex{}))
+
+	errs := make([]error, len(configs))
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = Generate(ctx, config, options...)
+		}()
+	}
+	wg.Wait()
+
+	var ds Diagnostics
+	for i, err := range errs {
+		for _, d := range DiagnosticsFromError(err) {
+			if d.Target == "" {
+				d.Target = configs[i].Target
+			}
+			ds = append(ds, d)
+		}
+	}
+	if len(ds) > 0 {
+		return ds
+	}
+	return nil
+}
+
+// CheckTargets checks every config like Check as the targets of one run, see GenerateTargets.
+// The reports are returned in the order of the configs.
+func CheckTargets(ctx context.Context, configs []*Config, options ...Option) ([]*CheckReport, error) {
+	changesets := make([]*Changeset, len(configs))
+	targets := make([]*Config, len(configs))
+	for i, config := range configs {
+		changesets[i] = NewChangeset()
+		target := *config
+		target.DryRun = true
+		target.Changeset = changesets[i]
+		targets[i] = &target
+	}
+	if err := GenerateTargets(ctx, targets, options...); err != nil {
+		return nil, err
+	}
+	reports := make([]*CheckReport, len(configs))
+	for i, config := range configs {
+		report, err := newCheckReport(changesets[i], config.outputFS())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check target %s", config.Target)
+		}
+		report.Target = config.Target
+		reports[i] = report
+	}
+	return reports, nil
+}
+
+func validateTargets(configs []*Config) error {
+	if len(configs) == 0 {
+		return errors.New("no targets")
+	}
+	seen := make(map[string]bool)
+	for _, config := range configs {
+		if config.Target == "" {
+			return errors.New("target name is required")
+		}
+		if seen[config.Target] {
+			return errors.Errorf("duplicated target %s", config.Target)
+		}
+		seen[config.Target] = true
+	}
+	return nil
+}
+
+// serializeCallbacks guards the callbacks of the config with mu, which is shared by the targets.
+func serializeCallbacks(mu *sync.Mutex) Option {
+	return func(conf *Config) error {
+		if fn := conf.OnDiagnostic; fn != nil {
+			conf.OnDiagnostic = func(d *Diagnostic) {
+				mu.Lock()
+				defer mu.Unlock()
+				fn(d)
+			}
+		}
+		if fn := conf.OnEvent; fn != nil {
+			conf.OnEvent = func(e *Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				fn(e)
+			}
+		}
+		return nil
+	}
+}
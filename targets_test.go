@@ -0,0 +1,99 @@
+package genx_test
+
+import (
+	"context"
+	"io/fs"
+	"sync/atomic"
+	"testing"
+
+	"github.com/molon/genx"
+	"github.com/molon/genx/extension/relayext"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTargets(t *testing.T) {
+	var formatted atomic.Int32
+	formatters := map[string]genx.Formatter{
+		".go": genx.FormatterFunc(func(ctx context.Context, text string) (string, error) {
+			formatted.Add(1)
+			return genx.FormatGo(text)
+		}),
+	}
+	newTarget := func(name, prototype string) *genx.Config {
+		return &genx.Config{
+			Target:              name,
+			FS:                  newPrototypeFS(t, prototype),
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/example/app",
+			Extensions:          []genx.Extension{relayext.New()},
+			Formatters:          formatters,
+		}
+	}
+
+	// identical services format their files once
+	prototype := "type User @node {\n  name: String!\n}\n"
+	users, accounts := newTarget("users", prototype), newTarget("accounts", prototype)
+	var diagnostics []*genx.Diagnostic
+	err := genx.GenerateTargets(context.Background(), []*genx.Config{users, accounts},
+		genx.OnDiagnostic(func(d *genx.Diagnostic) {
+			diagnostics = append(diagnostics, d)
+		}),
+	)
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics)
+	usersModels, err := fs.ReadFile(users.FS, "server/model/models.genx.go")
+	require.NoError(t, err)
+	accountsModels, err := fs.ReadFile(accounts.FS, "server/model/models.genx.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(usersModels), string(accountsModels))
+	goFiles := 0
+	for _, name := range users.FS.(*genx.MemFS).Names() {
+		if len(name) > 3 && name[len(name)-3:] == ".go" {
+			goFiles++
+		}
+	}
+	assert.Equal(t, int32(goFiles), formatted.Load())
+
+	// a failing target does not keep the others from being generated
+	posts, broken := newTarget("posts", "type Post @node {\n  title: String!\n}\n"), newTarget("broken", "type Broken @node {\n  title: Unknown!\n}\n")
+	err = genx.GenerateTargets(context.Background(), []*genx.Config{posts, broken})
+	var ds genx.Diagnostics
+	require.True(t, errors.As(err, &ds), "%+v", err)
+	require.NotEmpty(t, ds)
+	for _, d := range ds {
+		assert.Equal(t, "broken", d.Target)
+	}
+	assert.Contains(t, ds[0].String(), "[broken] ")
+	_, err = fs.Stat(posts.FS, "server/model/models.genx.go")
+	assert.NoError(t, err)
+	_, err = fs.Stat(broken.FS, "server/model/models.genx.go")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	err = genx.GenerateTargets(context.Background(), []*genx.Config{newTarget("users", prototype), newTarget("users", prototype)})
+	assert.ErrorContains(t, err, "duplicated target users")
+}
+
+func TestCheckTargets(t *testing.T) {
+	prototype := "type User @node {\n  name: String!\n}\n"
+	newTarget := func(name string) *genx.Config {
+		return &genx.Config{
+			Target:              name,
+			FS:                  newPrototypeFS(t, prototype),
+			PrototypeRelPattern: "prototype.graphql",
+			GoModule:            "github.com/example/app",
+			Extensions:          []genx.Extension{relayext.New()},
+		}
+	}
+	generated := newTarget("generated")
+	require.NoError(t, genx.Generate(context.Background(), generated))
+
+	generated.Extensions = []genx.Extension{relayext.New()}
+	reports, err := genx.CheckTargets(context.Background(), []*genx.Config{generated, newTarget("missing")})
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.True(t, reports[0].OK())
+	assert.False(t, reports[1].OK())
+	assert.Contains(t, reports[1].String(), "[missing] missing: server/model/models.genx.go")
+}